@@ -0,0 +1,143 @@
+package mbr
+
+import "testing"
+
+func blankMBR() *MBR {
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	return &MBR{bytes: mbrSector}
+}
+
+func Test_FreeSpace_Empty(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(1000)
+
+	gaps := m.FreeSpace()
+	if len(gaps) != 1 || gaps[0] != (Gap{StartLBA: 1, LenSectors: 999}) {
+		t.Errorf("unexpected gaps: %+v", gaps)
+	}
+}
+
+func Test_FreeSpace_BetweenPartitions(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(1000)
+
+	p1 := m.GetPartition(1)
+	p1.SetType(PART_LVM)
+	p1.SetLBAStart(100)
+	p1.SetLBALen(50)
+
+	p2 := m.GetPartition(2)
+	p2.SetType(PART_LVM)
+	p2.SetLBAStart(300)
+	p2.SetLBALen(50)
+
+	gaps := m.FreeSpace()
+	want := []Gap{
+		{StartLBA: 1, LenSectors: 99},
+		{StartLBA: 150, LenSectors: 150},
+		{StartLBA: 350, LenSectors: 650},
+	}
+	if len(gaps) != len(want) {
+		t.Fatalf("expected %d gaps, got %+v", len(want), gaps)
+	}
+	for i := range want {
+		if gaps[i] != want[i] {
+			t.Errorf("gap %d: got %+v, want %+v", i, gaps[i], want[i])
+		}
+	}
+}
+
+func Test_FreeSpace_NoDiskSizeOmitsTrailingGap(t *testing.T) {
+	m := blankMBR()
+
+	p1 := m.GetPartition(1)
+	p1.SetType(PART_LVM)
+	p1.SetLBAStart(100)
+	p1.SetLBALen(50)
+
+	gaps := m.FreeSpace()
+	want := []Gap{{StartLBA: 1, LenSectors: 99}}
+	if len(gaps) != 1 || gaps[0] != want[0] {
+		t.Errorf("unexpected gaps: %+v", gaps)
+	}
+}
+
+func Test_AddPartition_AlignsAndFillsSlot(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(1 << 20)
+
+	part, err := m.AddPartition(2048, PART_LVM, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Num != 1 {
+		t.Errorf("expected slot 1, got %d", part.Num)
+	}
+	if part.GetLBAStart() != DefaultAlignmentSectors {
+		t.Errorf("expected start aligned to %d, got %d", DefaultAlignmentSectors, part.GetLBAStart())
+	}
+	if !part.IsBootable() {
+		t.Error("expected partition to be bootable")
+	}
+}
+
+func Test_AddPartition_NoFreeSlot(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(1 << 20)
+
+	for i := 0; i < 4; i++ {
+		if _, err := m.AddPartition(2048, PART_LVM, false, 0); err != nil {
+			t.Fatalf("partition %d: %v", i, err)
+		}
+	}
+
+	if _, err := m.AddPartition(2048, PART_LVM, false, 0); err != ErrorNoFreePartitionSlot {
+		t.Errorf("expected ErrorNoFreePartitionSlot, got %v", err)
+	}
+}
+
+func Test_AddPartition_NoFreeSpace(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(3000)
+
+	p1 := m.GetPartition(1)
+	p1.SetType(PART_LVM)
+	p1.SetLBAStart(1)
+	p1.SetLBALen(2999)
+
+	if _, err := m.AddPartition(2048, PART_LVM, false, 0); err != ErrorNoFreeSpace {
+		t.Errorf("expected ErrorNoFreeSpace, got %v", err)
+	}
+}
+
+func Test_AddPartitionAt_RejectsOverlap(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(1000)
+
+	p1 := m.GetPartition(1)
+	p1.SetType(PART_LVM)
+	p1.SetLBAStart(100)
+	p1.SetLBALen(50)
+
+	if _, err := m.AddPartitionAt(120, 10, PART_LVM, false); err != ErrorPartitionOverlap {
+		t.Errorf("expected ErrorPartitionOverlap, got %v", err)
+	}
+
+	part, err := m.AddPartitionAt(200, 10, PART_LVM, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Num != 2 || part.GetLBAStart() != 200 || part.GetLBALen() != 10 {
+		t.Errorf("unexpected partition: %+v", part)
+	}
+}
+
+func Test_AddPartitionAt_RejectsPastDiskSize(t *testing.T) {
+	m := blankMBR()
+	m.SetDiskSizeSectors(100)
+
+	if _, err := m.AddPartitionAt(90, 20, PART_LVM, false); err != ErrorPartitionOverlap {
+		t.Errorf("expected ErrorPartitionOverlap, got %v", err)
+	}
+}
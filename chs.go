@@ -0,0 +1,118 @@
+package mbr
+
+const chsStartOffset = 1 // bytes, within a partition entry
+const chsEndOffset = 5   // bytes, within a partition entry
+
+const chsMaxCylinder = 1023
+const chsMaxHead = 254
+const chsMaxSector = 63
+
+// CHS is a Cylinder-Head-Sector address, the legacy geometry-based way of
+// addressing a sector that many BIOSes and bootloaders still consult
+// alongside (or instead of) the LBA fields.
+type CHS struct {
+	Cylinder uint16
+	Head     uint8
+	Sector   uint8
+}
+
+// chsOverflow is the standard "LBA too big for CHS" sentinel: raw on-disk
+// bytes 0xFE, 0xFF, 0xFF.
+var chsOverflow = CHS{Cylinder: chsMaxCylinder, Head: chsMaxHead, Sector: chsMaxSector}
+
+// Geometry describes the disk geometry used to translate between LBA and
+// CHS addresses.
+type Geometry struct {
+	HeadsPerCylinder uint16
+	SectorsPerTrack  uint8
+}
+
+// DefaultGeometry is the geometry assumed by most modern tools that still
+// bother writing CHS fields at all.
+var DefaultGeometry = Geometry{HeadsPerCylinder: 255, SectorsPerTrack: 63}
+
+/*
+GetStartCHS returns the starting CHS address packed into bytes 1-3 of the
+partition entry.
+*/
+func (this *MBRPartition) GetStartCHS() CHS {
+	return decodeCHS(this.bytes[chsStartOffset : chsStartOffset+3])
+}
+
+/*
+GetEndCHS returns the ending CHS address packed into bytes 5-7 of the
+partition entry.
+*/
+func (this *MBRPartition) GetEndCHS() CHS {
+	return decodeCHS(this.bytes[chsEndOffset : chsEndOffset+3])
+}
+
+/*
+SetStartCHS packs chs into bytes 1-3 of the partition entry.
+*/
+func (this *MBRPartition) SetStartCHS(chs CHS) {
+	encodeCHS(this.bytes[chsStartOffset:chsStartOffset+3], chs)
+}
+
+/*
+SetEndCHS packs chs into bytes 5-7 of the partition entry.
+*/
+func (this *MBRPartition) SetEndCHS(chs CHS) {
+	encodeCHS(this.bytes[chsEndOffset:chsEndOffset+3], chs)
+}
+
+// decodeCHS unpacks the 24-bit on-disk CHS encoding: head in byte 0, sector
+// in the low 6 bits of byte 1, cylinder in the high 2 bits of byte 1 plus
+// all of byte 2.
+func decodeCHS(buf []byte) CHS {
+	return CHS{
+		Head:     buf[0],
+		Sector:   buf[1] & 0x3F,
+		Cylinder: uint16(buf[1]&0xC0)<<2 | uint16(buf[2]),
+	}
+}
+
+func encodeCHS(buf []byte, chs CHS) {
+	buf[0] = chs.Head
+	buf[1] = chs.Sector&0x3F | byte(chs.Cylinder>>2)&0xC0
+	buf[2] = byte(chs.Cylinder)
+}
+
+/*
+RecomputeCHS fills in the start/end CHS fields of every non-empty partition
+from its LBA start/last, using geom for the heads-per-cylinder and
+sectors-per-track. Any LBA whose cylinder would exceed 1023 is clamped to
+the standard 0xFE/0xFF/0xFF "LBA too big" sentinel, per the usual BIOS
+convention.
+*/
+func (this *MBR) RecomputeCHS(geom Geometry) {
+	for _, p := range this.GetAllPartitions() {
+		if p.IsEmpty() {
+			continue
+		}
+		p.SetStartCHS(lbaToCHS(p.GetLBAStart(), geom))
+		p.SetEndCHS(lbaToCHS(p.GetLBALast(), geom))
+	}
+}
+
+func lbaToCHS(lba uint32, geom Geometry) CHS {
+	headsPerCylinder := uint32(geom.HeadsPerCylinder)
+	sectorsPerTrack := uint32(geom.SectorsPerTrack)
+
+	cylinder := lba / (headsPerCylinder * sectorsPerTrack)
+	if cylinder > chsMaxCylinder {
+		return chsOverflow
+	}
+
+	head := (lba / sectorsPerTrack) % headsPerCylinder
+	if head > chsMaxHead {
+		return chsOverflow
+	}
+
+	sector := lba%sectorsPerTrack + 1
+	if sector > chsMaxSector {
+		return chsOverflow
+	}
+
+	return CHS{Cylinder: uint16(cylinder), Head: uint8(head), Sector: uint8(sector)}
+}
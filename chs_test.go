@@ -0,0 +1,80 @@
+package mbr
+
+import "testing"
+
+func Test_CHS_RoundTrip(t *testing.T) {
+	part := &MBRPartition{bytes: make([]byte, mbrPartEntrySize)}
+
+	chs := CHS{Cylinder: 863, Head: 17, Sector: 42}
+	part.SetStartCHS(chs)
+	got := part.GetStartCHS()
+	if got != chs {
+		t.Errorf("start CHS round trip mismatch: got %+v, want %+v", got, chs)
+	}
+
+	part.SetEndCHS(chs)
+	if part.GetEndCHS() != chs {
+		t.Errorf("end CHS round trip mismatch: got %+v, want %+v", part.GetEndCHS(), chs)
+	}
+}
+
+func Test_RecomputeCHS(t *testing.T) {
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+
+	p := m.GetPartition(1)
+	p.SetType(PART_LVM)
+	p.SetLBAStart(63)
+	p.SetLBALen(16065) // exactly one cylinder at 255/63
+
+	m.RecomputeCHS(DefaultGeometry)
+
+	start := p.GetStartCHS()
+	if start != (CHS{Cylinder: 0, Head: 1, Sector: 1}) {
+		t.Errorf("unexpected start CHS: %+v", start)
+	}
+
+	end := p.GetEndCHS()
+	if end != (CHS{Cylinder: 1, Head: 0, Sector: 63}) {
+		t.Errorf("unexpected end CHS: %+v", end)
+	}
+}
+
+func Test_RecomputeCHS_Overflow(t *testing.T) {
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+
+	p := m.GetPartition(1)
+	p.SetType(PART_LVM)
+	p.SetLBAStart(0xFFFFFF)
+	p.SetLBALen(16065)
+
+	m.RecomputeCHS(DefaultGeometry)
+
+	if p.GetStartCHS() != chsOverflow {
+		t.Errorf("expected overflow sentinel, got %+v", p.GetStartCHS())
+	}
+}
+
+func Test_RecomputeCHS_HeadOverflow(t *testing.T) {
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+
+	p := m.GetPartition(1)
+	p.SetType(PART_LVM)
+	// With 300 heads/cylinder, LBA 17650 lands on head 280 ((17650/63) % 300),
+	// which exceeds chsMaxHead and must clamp instead of silently wrapping
+	// into a valid-looking uint8.
+	p.SetLBAStart(17650)
+	p.SetLBALen(63)
+
+	geom := Geometry{HeadsPerCylinder: 300, SectorsPerTrack: 63}
+	m.RecomputeCHS(geom)
+
+	if p.GetStartCHS() != chsOverflow {
+		t.Errorf("expected overflow sentinel, got %+v", p.GetStartCHS())
+	}
+}
@@ -0,0 +1,396 @@
+package mbr
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrorNotExtended = errors.New("MBR: No extended partition present")
+var ErrorEBRBadSignature = errors.New("MBR: Bad EBR signature")
+var ErrorEBRChainTooLong = errors.New("MBR: EBR chain is too long or contains a loop")
+var ErrorLogicalPartitionOutOfBounds = errors.New("MBR: Logical partition is not fully inside its extended partition")
+var ErrorLogicalPartitionsIntersection = errors.New("MBR: Logical partitions have intersections")
+var ErrorLogicalPartitionNotFound = errors.New("MBR: Logical partition not found")
+
+// maxEBRChainLength guards GetLogicalPartitions against malformed or
+// maliciously crafted disks whose EBR chain loops back on itself.
+const maxEBRChainLength = 100
+
+const logicalPartitionNumFirst = 5
+
+/*
+IsExtended returns true if this partition's type marks it as a container for
+a chain of logical partitions (a DOS extended partition).
+*/
+func (this *MBRPartition) IsExtended() bool {
+	switch this.GetType() {
+	case PART_EXTENDED_CHS, PART_EXTENDED_LBA, PART_EXTENDED_LINUX:
+		return true
+	default:
+		return false
+	}
+}
+
+// extendedPartition returns the first primary partition that is an
+// extended partition, or nil if there is none.
+func (this *MBR) extendedPartition() *MBRPartition {
+	for _, p := range this.GetAllPartitions() {
+		if p.IsExtended() {
+			return p
+		}
+	}
+	return nil
+}
+
+/*
+GetLogicalPartitions follows the chain of EBRs (Extended Boot Records)
+starting at the MBR's extended partition and returns the logical partitions
+it describes, numbered from 5 upward, with LBAStart already translated to
+an absolute disk LBA.
+
+Each EBR is a 512-byte sector whose first partition entry describes the
+logical partition itself (LBA relative to the EBR's own LBA) and whose
+second entry, if present, points to the next EBR in the chain (LBA relative
+to the start of the outer extended partition, not the current EBR).
+
+Returns (nil, nil) if there is no extended partition on this MBR, or if the
+extended partition's own LBA is still blank (untouched since AddPartition
+created it, before any logical partition was ever appended).
+*/
+func (this *MBR) GetLogicalPartitions(disk io.ReadSeeker) ([]*MBRPartition, error) {
+	extended := this.extendedPartition()
+	if extended == nil {
+		return nil, nil
+	}
+	extendedStart := extended.GetLBAStart()
+
+	var result []*MBRPartition
+	visited := map[uint32]bool{}
+	ebrLBA := extendedStart
+
+	for {
+		if len(visited) >= maxEBRChainLength {
+			return nil, ErrorEBRChainTooLong
+		}
+		if visited[ebrLBA] {
+			return nil, ErrorEBRChainTooLong
+		}
+		visited[ebrLBA] = true
+
+		sector, err := readSector(disk, ebrLBA)
+		if err != nil {
+			return nil, err
+		}
+		if sector[mbrSignOffset] != 0x55 || sector[mbrSignOffset+1] != 0xAA {
+			if ebrLBA == extendedStart && sectorIsBlank(sector) {
+				return nil, nil
+			}
+			return nil, ErrorEBRBadSignature
+		}
+
+		entry := ebrPartitionEntry(sector, 0)
+		next := ebrPartitionEntry(sector, 1)
+
+		if !entry.IsEmpty() {
+			logical := &MBRPartition{Num: logicalPartitionNumFirst + len(result), bytes: make([]byte, mbrPartEntrySize)}
+			copy(logical.bytes, entry.bytes)
+			logical.SetLBAStart(ebrLBA + entry.GetLBAStart())
+			result = append(result, logical)
+		}
+
+		if next.IsEmpty() || !next.IsExtended() {
+			return result, nil
+		}
+		ebrLBA = extendedStart + next.GetLBAStart()
+	}
+}
+
+// sectorIsBlank reports whether every byte of sector is zero: the state of
+// an extended partition's own LBA before any EBR has ever been written
+// there.
+func sectorIsBlank(sector []byte) bool {
+	for _, b := range sector {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ebrPartitionEntry returns the slot-th (0 or 1) partition entry of an EBR
+// sector. EBRs reuse the MBR's partition table layout (16-byte entries
+// starting at byte offset 446), but only the first two entries are
+// meaningful.
+func ebrPartitionEntry(sector []byte, slot int) *MBRPartition {
+	start := mbrFirstPartEntryOffset + slot*mbrPartEntrySize
+	return &MBRPartition{bytes: sector[start : start+mbrPartEntrySize]}
+}
+
+func readSector(disk io.ReadSeeker, lba uint32) ([]byte, error) {
+	if _, err := disk.Seek(int64(lba)*mbrSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, mbrSize)
+	if _, err := io.ReadFull(disk, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func writeSector(disk io.WriteSeeker, lba uint32, buf []byte) error {
+	if _, err := disk.Seek(int64(lba)*mbrSize, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := disk.Write(buf)
+	return err
+}
+
+// initHeadEBRIfBlank writes a signed, link-only (both entries empty) EBR
+// sector at extendedStart if nothing has been written there yet. It leaves
+// an already-initialized head EBR untouched.
+func initHeadEBRIfBlank(disk io.ReadWriteSeeker, extendedStart uint32) error {
+	sector, err := readSector(disk, extendedStart)
+	if err != nil {
+		return err
+	}
+	if !sectorIsBlank(sector) {
+		return nil
+	}
+
+	head := make([]byte, mbrSize)
+	head[mbrSignOffset], head[mbrSignOffset+1] = 0x55, 0xAA
+	return writeSector(disk, extendedStart, head)
+}
+
+/*
+AppendLogicalPartition adds a new logical partition of type partType,
+occupying [lbaStart, lbaStart+lbaLen) (absolute LBAs), to the end of the
+extended partition's EBR chain. It places the new EBR at lbaStart-1, so
+callers must leave at least one free sector immediately before lbaStart
+for it.
+
+If the extended partition's own LBA is still blank (the very first append
+against a freshly created extended partition), it is initialized in place
+with a signed, link-only head EBR before the new logical partition is
+chained onto it.
+*/
+func (this *MBR) AppendLogicalPartition(disk io.ReadWriteSeeker, partType PartitionType, lbaStart, lbaLen uint32) error {
+	extended := this.extendedPartition()
+	if extended == nil {
+		return ErrorNotExtended
+	}
+	extendedStart := extended.GetLBAStart()
+
+	if err := initHeadEBRIfBlank(disk, extendedStart); err != nil {
+		return err
+	}
+
+	newEBRLBA := lbaStart - 1
+
+	newSector := make([]byte, mbrSize)
+	newSector[mbrSignOffset], newSector[mbrSignOffset+1] = 0x55, 0xAA
+	entry := ebrPartitionEntry(newSector, 0)
+	entry.SetType(partType)
+	entry.SetLBAStart(lbaStart - newEBRLBA)
+	entry.SetLBALen(lbaLen)
+
+	visited := map[uint32]bool{}
+	ebrLBA := extendedStart
+	for {
+		if len(visited) >= maxEBRChainLength {
+			return ErrorEBRChainTooLong
+		}
+		if visited[ebrLBA] {
+			return ErrorEBRChainTooLong
+		}
+		visited[ebrLBA] = true
+
+		sector, err := readSector(disk, ebrLBA)
+		if err != nil {
+			return err
+		}
+		if sector[mbrSignOffset] != 0x55 || sector[mbrSignOffset+1] != 0xAA {
+			return ErrorEBRBadSignature
+		}
+
+		next := ebrPartitionEntry(sector, 1)
+		if next.IsEmpty() || !next.IsExtended() {
+			// ebrLBA is the last EBR in the chain: link it to the new one.
+			next.SetType(extended.GetType())
+			next.SetLBAStart(newEBRLBA - extendedStart)
+			next.SetLBALen(lbaLen)
+			if err := writeSector(disk, ebrLBA, sector); err != nil {
+				return err
+			}
+			return writeSector(disk, newEBRLBA, newSector)
+		}
+		ebrLBA = extendedStart + next.GetLBAStart()
+	}
+}
+
+/*
+RemoveLogicalPartition removes the logical partition numbered num (5, 6,
+7, ...) from the extended partition's EBR chain, relinking its neighbors so
+the chain stays contiguous.
+*/
+func (this *MBR) RemoveLogicalPartition(disk io.ReadWriteSeeker, num int) error {
+	extended := this.extendedPartition()
+	if extended == nil {
+		return ErrorNotExtended
+	}
+	extendedStart := extended.GetLBAStart()
+
+	type node struct {
+		ebrLBA   uint32
+		sector   []byte
+		nextSlot *MBRPartition
+	}
+	var chain []node
+
+	visited := map[uint32]bool{}
+	ebrLBA := extendedStart
+	for {
+		if len(visited) >= maxEBRChainLength {
+			return ErrorEBRChainTooLong
+		}
+		if visited[ebrLBA] {
+			return ErrorEBRChainTooLong
+		}
+		visited[ebrLBA] = true
+
+		sector, err := readSector(disk, ebrLBA)
+		if err != nil {
+			return err
+		}
+		if sector[mbrSignOffset] != 0x55 || sector[mbrSignOffset+1] != 0xAA {
+			return ErrorEBRBadSignature
+		}
+
+		next := ebrPartitionEntry(sector, 1)
+		chain = append(chain, node{ebrLBA: ebrLBA, sector: sector, nextSlot: next})
+
+		if next.IsEmpty() || !next.IsExtended() {
+			break
+		}
+		ebrLBA = extendedStart + next.GetLBAStart()
+	}
+
+	// Number chain nodes the same way GetLogicalPartitions does: the head
+	// EBR's own entry (at the extended partition's own LBA) is a link-only
+	// slot left empty by AppendLogicalPartition, and does not consume a
+	// partition number, so chain index and logical partition number are not
+	// simply offset by logicalPartitionNumFirst.
+	index := -1
+	nextNum := logicalPartitionNumFirst
+	for i, node := range chain {
+		if ebrPartitionEntry(node.sector, 0).IsEmpty() {
+			continue
+		}
+		if nextNum == num {
+			index = i
+			break
+		}
+		nextNum++
+	}
+	if index == -1 {
+		return ErrorLogicalPartitionNotFound
+	}
+
+	if index == len(chain)-1 {
+		// Removing the last logical partition: just clear its entry and,
+		// if it isn't the first EBR, unlink it from its predecessor.
+		entry := ebrPartitionEntry(chain[index].sector, 0)
+		entry.SetType(PART_EMPTY)
+		entry.SetLBAStart(0)
+		entry.SetLBALen(0)
+		if err := writeSector(disk, chain[index].ebrLBA, chain[index].sector); err != nil {
+			return err
+		}
+		if index > 0 {
+			prev := chain[index-1]
+			prev.nextSlot.SetType(PART_EMPTY)
+			prev.nextSlot.SetLBAStart(0)
+			prev.nextSlot.SetLBALen(0)
+			return writeSector(disk, prev.ebrLBA, prev.sector)
+		}
+		return nil
+	}
+
+	// Removing an interior logical partition: relink the previous EBR to
+	// skip over this one.
+	if index > 0 {
+		prev := chain[index-1]
+		skip := chain[index].nextSlot
+		prev.nextSlot.SetLBAStart(skip.GetLBAStart())
+		prev.nextSlot.SetLBALen(skip.GetLBALen())
+		return writeSector(disk, prev.ebrLBA, prev.sector)
+	}
+
+	// index == 0: the chain head's EBR sits at the extended partition's own
+	// LBA and cannot simply be unlinked, so fold the next EBR's logical
+	// entry into it instead, re-based to the head EBR's LBA.
+	follow := chain[1]
+	followEntry := ebrPartitionEntry(follow.sector, 0)
+	headEntry := ebrPartitionEntry(chain[0].sector, 0)
+	absoluteLBA := follow.ebrLBA + followEntry.GetLBAStart()
+	headEntry.SetType(followEntry.GetType())
+	headEntry.SetLBAStart(absoluteLBA - chain[0].ebrLBA)
+	headEntry.SetLBALen(followEntry.GetLBALen())
+
+	if follow.nextSlot.IsEmpty() || !follow.nextSlot.IsExtended() {
+		chain[0].nextSlot.SetType(PART_EMPTY)
+		chain[0].nextSlot.SetLBAStart(0)
+		chain[0].nextSlot.SetLBALen(0)
+	} else {
+		chain[0].nextSlot.SetLBAStart(follow.nextSlot.GetLBAStart())
+		chain[0].nextSlot.SetLBALen(follow.nextSlot.GetLBALen())
+	}
+
+	return writeSector(disk, chain[0].ebrLBA, chain[0].sector)
+}
+
+/*
+CheckLogicalPartitions validates that every logical partition lies fully
+inside the extended partition that contains it and that no two logical
+partitions overlap.
+
+It is a separate, opt-in call rather than something Check() performs
+automatically: Check() takes no disk argument, so it has no way to read the
+EBR chain that describes logical partitions. Callers that use extended
+partitions should call both Check() and CheckLogicalPartitions(disk).
+*/
+func (this *MBR) CheckLogicalPartitions(disk io.ReadSeeker) error {
+	extended := this.extendedPartition()
+	if extended == nil {
+		return nil
+	}
+
+	logicals, err := this.GetLogicalPartitions(disk)
+	if err != nil {
+		return err
+	}
+
+	containerStart := uint64(extended.GetLBAStart())
+	containerEnd := containerStart + uint64(extended.GetLBALen())
+
+	for i, lp := range logicals {
+		start := uint64(lp.GetLBAStart())
+		end := start + uint64(lp.GetLBALen())
+		if start < containerStart || end > containerEnd {
+			return ErrorLogicalPartitionOutOfBounds
+		}
+
+		for j, other := range logicals {
+			if i == j {
+				continue
+			}
+			otherStart := uint64(other.GetLBAStart())
+			otherEnd := otherStart + uint64(other.GetLBALen())
+			if start < otherEnd && otherStart < end {
+				return ErrorLogicalPartitionsIntersection
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package gpt
+
+import "sort"
+
+// AddPartition finds the first free entry slot and the first free-space gap
+// (between FirstUsableLBA and LastUsableLBA) big enough for sizeSectors,
+// aligning the start LBA up to alignSectors (0 means DefaultAlignment).
+// It returns the newly filled-in Partition.
+func (this *GPT) AddPartition(sizeSectors uint64, typeGUID GUID, name string, alignSectors uint64) (*Partition, error) {
+	if alignSectors == 0 {
+		alignSectors = DefaultAlignment
+	}
+
+	slot := -1
+	for i, p := range this.Partitions {
+		if p.IsEmpty() {
+			slot = i
+			break
+		}
+	}
+	if slot == -1 {
+		return nil, ErrorNoFreeEntrySlot
+	}
+
+	startLBA, ok := this.findGap(sizeSectors, alignSectors)
+	if !ok {
+		return nil, ErrorNoFreeSpace
+	}
+
+	uniqueGUID, err := NewRandomGUID()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Partition{
+		TypeGUID:   typeGUID,
+		UniqueGUID: uniqueGUID,
+		FirstLBA:   startLBA,
+		LastLBA:    startLBA + sizeSectors - 1,
+		Name:       name,
+	}
+	this.Partitions[slot] = p
+
+	return p, nil
+}
+
+// findGap returns the first usable-space gap of at least sizeSectors,
+// aligned up to alignSectors.
+func (this *GPT) findGap(sizeSectors uint64, alignSectors uint64) (uint64, bool) {
+	type region struct{ start, end uint64 } // inclusive
+	var used []region
+	for _, p := range this.Partitions {
+		if !p.IsEmpty() {
+			used = append(used, region{p.FirstLBA, p.LastLBA})
+		}
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].start < used[j].start })
+
+	cursor := this.Primary.FirstUsableLBA
+	for _, r := range used {
+		start := alignUp(cursor, alignSectors)
+		if r.start > start && r.start-start >= sizeSectors {
+			return start, true
+		}
+		if r.end+1 > cursor {
+			cursor = r.end + 1
+		}
+	}
+
+	start := alignUp(cursor, alignSectors)
+	if this.Primary.LastUsableLBA >= start && this.Primary.LastUsableLBA-start+1 >= sizeSectors {
+		return start, true
+	}
+
+	return 0, false
+}
+
+func alignUp(lba uint64, align uint64) uint64 {
+	if align <= 1 {
+		return lba
+	}
+	rem := lba % align
+	if rem == 0 {
+		return lba
+	}
+	return lba + (align - rem)
+}
+
+// RemovePartition clears the entry at index, freeing its space.
+func (this *GPT) RemovePartition(index int) error {
+	if index < 0 || index >= len(this.Partitions) {
+		return ErrorPartitionNotFound
+	}
+	this.Partitions[index] = &Partition{}
+	return nil
+}
+
+// ResizePartition changes the length of the partition at index to
+// newSizeSectors, keeping its start LBA fixed. It rejects a zero size
+// (ErrorInvalidSize), a size that would run past Primary.LastUsableLBA
+// (ErrorNoFreeSpace), and a size that would overlap a neighboring partition
+// (ErrorPartitionOverlap).
+func (this *GPT) ResizePartition(index int, newSizeSectors uint64) error {
+	if index < 0 || index >= len(this.Partitions) {
+		return ErrorPartitionNotFound
+	}
+	p := this.Partitions[index]
+	if p.IsEmpty() {
+		return ErrorPartitionNotFound
+	}
+	if newSizeSectors == 0 {
+		return ErrorInvalidSize
+	}
+
+	newLastLBA := p.FirstLBA + newSizeSectors - 1
+	if newLastLBA > this.Primary.LastUsableLBA {
+		return ErrorNoFreeSpace
+	}
+
+	for i, other := range this.Partitions {
+		if i == index || other.IsEmpty() {
+			continue
+		}
+		if p.FirstLBA <= other.LastLBA && other.FirstLBA <= newLastLBA {
+			return ErrorPartitionOverlap
+		}
+	}
+
+	p.LastLBA = newLastLBA
+	return nil
+}
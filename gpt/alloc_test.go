@@ -0,0 +1,135 @@
+package gpt
+
+import "testing"
+
+func blankTable(t *testing.T) *GPT {
+	t.Helper()
+	table, err := New(512, 1<<16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func Test_AddPartition_NoFreeEntrySlot(t *testing.T) {
+	table := blankTable(t)
+	table.Partitions = table.Partitions[:2]
+
+	for i := 0; i < 2; i++ {
+		if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "", 0); err != nil {
+			t.Fatalf("partition %d: %v", i, err)
+		}
+	}
+
+	if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "", 0); err != ErrorNoFreeEntrySlot {
+		t.Errorf("expected ErrorNoFreeEntrySlot, got %v", err)
+	}
+}
+
+func Test_AddPartition_NoFreeSpace(t *testing.T) {
+	table := blankTable(t)
+
+	usable := table.Primary.LastUsableLBA - table.Primary.FirstUsableLBA + 1
+	if _, err := table.AddPartition(usable+1, TypeLinuxFilesystem, "", 1); err != ErrorNoFreeSpace {
+		t.Errorf("expected ErrorNoFreeSpace, got %v", err)
+	}
+}
+
+func Test_RemovePartition(t *testing.T) {
+	table := blankTable(t)
+	p, err := table.AddPartition(2048, TypeLinuxFilesystem, "root", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slot := -1
+	for i, entry := range table.Partitions {
+		if entry == p {
+			slot = i
+		}
+	}
+	if slot == -1 {
+		t.Fatal("could not find added partition's slot")
+	}
+
+	if err := table.RemovePartition(slot); err != nil {
+		t.Fatal(err)
+	}
+	if !table.Partitions[slot].IsEmpty() {
+		t.Errorf("expected slot %d to be empty after removal", slot)
+	}
+}
+
+func Test_RemovePartition_NotFound(t *testing.T) {
+	table := blankTable(t)
+	if err := table.RemovePartition(len(table.Partitions)); err != ErrorPartitionNotFound {
+		t.Errorf("expected ErrorPartitionNotFound, got %v", err)
+	}
+	if err := table.RemovePartition(-1); err != ErrorPartitionNotFound {
+		t.Errorf("expected ErrorPartitionNotFound for negative index, got %v", err)
+	}
+}
+
+func Test_ResizePartition_Grows(t *testing.T) {
+	table := blankTable(t)
+	p, err := table.AddPartition(2048, TypeLinuxFilesystem, "root", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.ResizePartition(0, 4096); err != nil {
+		t.Fatal(err)
+	}
+	if p.LastLBA != p.FirstLBA+4095 {
+		t.Errorf("expected LastLBA %d, got %d", p.FirstLBA+4095, p.LastLBA)
+	}
+}
+
+func Test_ResizePartition_RejectsZeroSize(t *testing.T) {
+	table := blankTable(t)
+	if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "root", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.ResizePartition(0, 0); err != ErrorInvalidSize {
+		t.Errorf("expected ErrorInvalidSize, got %v", err)
+	}
+}
+
+func Test_ResizePartition_RejectsPastLastUsableLBA(t *testing.T) {
+	table := blankTable(t)
+	if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "root", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	usable := table.Primary.LastUsableLBA - table.Partitions[0].FirstLBA + 1
+	if err := table.ResizePartition(0, usable+1); err != ErrorNoFreeSpace {
+		t.Errorf("expected ErrorNoFreeSpace, got %v", err)
+	}
+}
+
+func Test_ResizePartition_RejectsOverlap(t *testing.T) {
+	table := blankTable(t)
+	first, err := table.AddPartition(2048, TypeLinuxFilesystem, "first", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "second", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	grown := table.Partitions[1].FirstLBA - first.FirstLBA + 1
+	if err := table.ResizePartition(0, grown); err != ErrorPartitionOverlap {
+		t.Errorf("expected ErrorPartitionOverlap, got %v", err)
+	}
+}
+
+func Test_ResizePartition_NotFound(t *testing.T) {
+	table := blankTable(t)
+	if err := table.ResizePartition(len(table.Partitions), 2048); err != ErrorPartitionNotFound {
+		t.Errorf("expected ErrorPartitionNotFound, got %v", err)
+	}
+	if err := table.ResizePartition(0, 2048); err != ErrorPartitionNotFound {
+		t.Errorf("expected ErrorPartitionNotFound for empty slot, got %v", err)
+	}
+}
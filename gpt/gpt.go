@@ -0,0 +1,272 @@
+/*
+Package gpt reads, validates, modifies and writes a UEFI-spec GUID Partition
+Table, on top of the protective MBR support in the parent mbr package.
+
+Example:
+
+	f, _ := os.Open("/dev/sda")
+	table, err := gpt.Read(f, 512, diskSizeSectors)
+	if err != nil ...
+	f.Close()
+*/
+package gpt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/rekby/mbr"
+)
+
+var ErrorBothHeadersBad = errors.New("GPT: Both primary and backup headers are invalid")
+var ErrorHeadersDiverge = errors.New("GPT: Primary and backup headers disagree")
+var ErrorNoFreeEntrySlot = errors.New("GPT: No free partition entry slot")
+var ErrorNoFreeSpace = errors.New("GPT: No free space for partition of requested size")
+var ErrorPartitionNotFound = errors.New("GPT: Partition not found")
+var ErrorBadSectorSize = errors.New("GPT: sectorSize must be 512 or 4096")
+var ErrorPartitionOverlap = errors.New("GPT: partition overlaps an existing one")
+var ErrorInvalidSize = errors.New("GPT: partition size must be greater than zero")
+
+const DefaultAlignment = 2048 // sectors = 1 MiB at 512 bytes/sector
+
+// GPT is an in-memory representation of a GUID Partition Table: the primary
+// and backup headers (kept in sync with each other) and the partition
+// entries array.
+type GPT struct {
+	SectorSize int
+	Primary    Header
+	Backup     Header
+	Partitions []*Partition
+}
+
+// Read parses a GPT from disk, reading the primary header at LBA 1, its
+// partition entries array, and the backup header at the last LBA of
+// diskSizeSectors. It verifies the CRC32 of both headers and of
+// the partition entries array, falling back to the backup copy if the
+// primary is corrupt, and returns an error only if both copies are bad. If
+// the primary is fine, it is also cross-checked against the backup header
+// (when the backup itself is readable): a backup that parses and CRCs
+// cleanly but disagrees with the primary is reported as ErrorHeadersDiverge
+// rather than silently ignored.
+func Read(disk io.ReadSeeker, sectorSize int, diskSizeSectors uint64) (*GPT, error) {
+	if sectorSize != 512 && sectorSize != 4096 {
+		return nil, ErrorBadSectorSize
+	}
+
+	primaryHeader, primaryErr := readHeaderAt(disk, sectorSize, 1)
+
+	var table *GPT
+	var headerErr error
+	if primaryErr == nil {
+		table, headerErr = readFromHeader(disk, sectorSize, primaryHeader, true)
+	}
+
+	if primaryErr != nil || headerErr != nil {
+		backupHeader, backupErr := readHeaderAt(disk, sectorSize, diskSizeSectors-1)
+		if backupErr != nil {
+			return nil, ErrorBothHeadersBad
+		}
+		backupTable, err := readFromHeader(disk, sectorSize, backupHeader, false)
+		if err != nil {
+			return nil, ErrorBothHeadersBad
+		}
+		return backupTable, nil
+	}
+
+	if err := crossCheckBackup(disk, sectorSize, diskSizeSectors, table); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// crossCheckBackup re-reads the backup header and, only if it parses and
+// CRCs cleanly on its own terms, verifies it actually agrees with table's
+// primary. A backup that is missing or corrupt is not an error here (the
+// primary read cleanly, so Read returns it); a backup that is well-formed
+// but describes a different disk is.
+func crossCheckBackup(disk io.ReadSeeker, sectorSize int, diskSizeSectors uint64, table *GPT) error {
+	backupHeader, err := readHeaderAt(disk, sectorSize, diskSizeSectors-1)
+	if err != nil {
+		return nil
+	}
+
+	if backupHeader.DiskGUID != table.Primary.DiskGUID ||
+		backupHeader.MyLBA != table.Backup.MyLBA ||
+		backupHeader.AlternateLBA != table.Backup.AlternateLBA ||
+		backupHeader.PartitionEntryArrayCRC32 != table.Primary.PartitionEntryArrayCRC32 {
+		return ErrorHeadersDiverge
+	}
+	return nil
+}
+
+func readHeaderAt(disk io.ReadSeeker, sectorSize int, lba uint64) (*Header, error) {
+	if _, err := disk.Seek(int64(lba)*int64(sectorSize), io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, sectorSize)
+	if _, err := io.ReadFull(disk, buf); err != nil {
+		return nil, err
+	}
+	return parseHeader(buf)
+}
+
+// readFromHeader reads the partition entries array described by h and
+// verifies its CRC32, returning a populated GPT on success. isPrimary tells
+// it whether h was read from LBA 1 (the primary) or from the disk's last
+// LBA (the backup), so it assigns h to the right half of the GPT and
+// derives the other (unread) half's mirrored fields accordingly.
+func readFromHeader(disk io.ReadSeeker, sectorSize int, h *Header, isPrimary bool) (*GPT, error) {
+	entriesSize := int(h.NumberOfPartitionEntries) * int(h.SizeOfPartitionEntry)
+	if _, err := disk.Seek(int64(h.PartitionEntryLBA)*int64(sectorSize), io.SeekStart); err != nil {
+		return nil, err
+	}
+	entriesBuf := make([]byte, entriesSize)
+	if _, err := io.ReadFull(disk, entriesBuf); err != nil {
+		return nil, err
+	}
+	if crc32Entries(entriesBuf) != h.PartitionEntryArrayCRC32 {
+		return nil, ErrorBadPartitionArrayCRC
+	}
+
+	partitions := make([]*Partition, h.NumberOfPartitionEntries)
+	for i := range partitions {
+		off := i * int(h.SizeOfPartitionEntry)
+		partitions[i] = parsePartitionEntry(entriesBuf[off : off+partitionEntrySize])
+	}
+
+	table := &GPT{
+		SectorSize: sectorSize,
+		Partitions: partitions,
+	}
+
+	if isPrimary {
+		table.Primary = *h
+		table.Backup = *h
+		table.Backup.MyLBA, table.Backup.AlternateLBA = h.AlternateLBA, h.MyLBA
+		// The backup's entries array always sits immediately before its own
+		// header, at the end of the disk.
+		table.Backup.PartitionEntryLBA = table.Backup.MyLBA - uint64(entriesSectors(sectorSize, h.NumberOfPartitionEntries, h.SizeOfPartitionEntry))
+	} else {
+		table.Backup = *h
+		table.Primary = *h
+		table.Primary.MyLBA, table.Primary.AlternateLBA = h.AlternateLBA, h.MyLBA
+		// The primary's entries array always starts right after its header,
+		// at LBA 2.
+		table.Primary.PartitionEntryLBA = 2
+	}
+
+	return table, nil
+}
+
+// New creates a fresh, empty GPT sized for a disk of diskSizeSectors
+// sectors of sectorSize bytes (512 or 4096), with a freshly generated disk
+// GUID and default-sized (128-entry) partition array.
+func New(sectorSize int, diskSizeSectors uint64) (*GPT, error) {
+	if sectorSize != 512 && sectorSize != 4096 {
+		return nil, ErrorBadSectorSize
+	}
+
+	diskGUID, err := NewRandomGUID()
+	if err != nil {
+		return nil, err
+	}
+
+	entrySectors := entriesSectors(sectorSize, defaultNumberOfPartitionEntries, partitionEntrySize)
+
+	table := &GPT{
+		SectorSize: sectorSize,
+		Partitions: make([]*Partition, defaultNumberOfPartitionEntries),
+	}
+	for i := range table.Partitions {
+		table.Partitions[i] = &Partition{}
+	}
+
+	table.Primary.MyLBA = 1
+	table.Primary.AlternateLBA = diskSizeSectors - 1
+	table.Primary.PartitionEntryLBA = 2
+	table.Primary.FirstUsableLBA = 2 + uint64(entrySectors)
+	table.Primary.LastUsableLBA = diskSizeSectors - 2 - uint64(entrySectors)
+	table.Primary.DiskGUID = diskGUID
+	table.Primary.NumberOfPartitionEntries = defaultNumberOfPartitionEntries
+	table.Primary.SizeOfPartitionEntry = partitionEntrySize
+
+	table.Backup = table.Primary
+	table.Backup.MyLBA = table.Primary.AlternateLBA
+	table.Backup.AlternateLBA = table.Primary.MyLBA
+	table.Backup.PartitionEntryLBA = table.Primary.LastUsableLBA + 1
+
+	return table, nil
+}
+
+func entriesSectors(sectorSize int, numberOfEntries uint32, sizeOfEntry uint32) int {
+	total := int(numberOfEntries) * int(sizeOfEntry)
+	return (total + sectorSize - 1) / sectorSize
+}
+
+// Write emits a full GPT layout: protective MBR (LBA 0), primary header
+// (LBA 1), primary partition entries (starting LBA 2), backup partition
+// entries (the sectors just before the backup header) and backup header
+// (the last LBA of the disk), recomputing every CRC32 and keeping the
+// mirrored header fields in sync.
+func (this *GPT) Write(disk io.WriteSeeker) error {
+	blank := make([]byte, 512)
+	blank[510], blank[511] = 0x55, 0xAA
+	protectiveMBR, err := mbr.Read(bytes.NewReader(blank))
+	if err != nil {
+		return err
+	}
+	diskSizeBytes := (this.Primary.AlternateLBA + 1) * uint64(this.SectorSize)
+	if err := protectiveMBR.MakeProtective(this.SectorSize, diskSizeBytes, mbr.DefaultProtective); err != nil {
+		return err
+	}
+	if _, err := disk.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := protectiveMBR.Write(disk); err != nil {
+		return err
+	}
+
+	this.Primary.NumberOfPartitionEntries = uint32(len(this.Partitions))
+	this.Primary.SizeOfPartitionEntry = partitionEntrySize
+	this.Backup.NumberOfPartitionEntries = this.Primary.NumberOfPartitionEntries
+	this.Backup.SizeOfPartitionEntry = this.Primary.SizeOfPartitionEntry
+
+	entriesBuf := this.marshalEntries()
+	entriesCRC := crc32Entries(entriesBuf)
+	this.Primary.PartitionEntryArrayCRC32 = entriesCRC
+	this.Backup.PartitionEntryArrayCRC32 = entriesCRC
+
+	if err := this.writeAt(disk, this.Primary.PartitionEntryLBA, entriesBuf); err != nil {
+		return err
+	}
+	if err := this.writeAt(disk, this.Backup.PartitionEntryLBA, entriesBuf); err != nil {
+		return err
+	}
+
+	if err := this.writeAt(disk, this.Primary.MyLBA, this.Primary.marshal(this.SectorSize)); err != nil {
+		return err
+	}
+	if err := this.writeAt(disk, this.Backup.MyLBA, this.Backup.marshal(this.SectorSize)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (this *GPT) marshalEntries() []byte {
+	buf := make([]byte, len(this.Partitions)*partitionEntrySize)
+	for i, p := range this.Partitions {
+		copy(buf[i*partitionEntrySize:], p.marshal(partitionEntrySize))
+	}
+	return buf
+}
+
+func (this *GPT) writeAt(disk io.WriteSeeker, lba uint64, buf []byte) error {
+	if _, err := disk.Seek(int64(lba)*int64(this.SectorSize), io.SeekStart); err != nil {
+		return err
+	}
+	_, err := disk.Write(buf)
+	return err
+}
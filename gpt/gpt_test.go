@@ -0,0 +1,146 @@
+package gpt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_ParseGUID(t *testing.T) {
+	s := "C12A7328-F81F-11D2-BA4B-00A0C93EC93B"
+	guid, err := ParseGUID(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guid.String() != s {
+		t.Errorf("round trip mismatch: got %s, want %s", guid.String(), s)
+	}
+	if guid != TypeEFISystem {
+		t.Error("parsed GUID does not match TypeEFISystem registry entry")
+	}
+}
+
+func Test_NewWriteRead(t *testing.T) {
+	const sectorSize = 512
+	const diskSizeSectors = 1 << 16
+
+	table, err := New(sectorSize, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := table.AddPartition(2048, TypeLinuxFilesystem, "root", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	disk := bytes.NewBuffer(make([]byte, diskSizeSectors*sectorSize))
+	diskRW := &seekBuffer{buf: disk.Bytes()}
+	if err := table.Write(diskRW); err != nil {
+		t.Fatal(err)
+	}
+
+	readBack, err := Read(&seekBuffer{buf: diskRW.buf}, sectorSize, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readBack.Primary.DiskGUID != table.Primary.DiskGUID {
+		t.Error("disk GUID did not round trip")
+	}
+	if readBack.Partitions[0].Name != "root" {
+		t.Errorf("partition name did not round trip, got %q", readBack.Partitions[0].Name)
+	}
+}
+
+func Test_Read_FallsBackToBackupWithCorrectLabels(t *testing.T) {
+	const sectorSize = 512
+	const diskSizeSectors = 1 << 16
+
+	table, err := New(sectorSize, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := &seekBuffer{buf: make([]byte, diskSizeSectors*sectorSize)}
+	if err := table.Write(disk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the primary header sector (LBA 1) so Read must fall back.
+	for i := 0; i < sectorSize; i++ {
+		disk.buf[sectorSize+i] = 0
+	}
+
+	readBack, err := Read(&seekBuffer{buf: disk.buf}, sectorSize, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readBack.Primary.MyLBA != 1 {
+		t.Errorf("expected Primary.MyLBA == 1, got %d", readBack.Primary.MyLBA)
+	}
+	if readBack.Backup.MyLBA != diskSizeSectors-1 {
+		t.Errorf("expected Backup.MyLBA == %d, got %d", diskSizeSectors-1, readBack.Backup.MyLBA)
+	}
+	if readBack.Primary.AlternateLBA != diskSizeSectors-1 {
+		t.Errorf("expected Primary.AlternateLBA == %d, got %d", diskSizeSectors-1, readBack.Primary.AlternateLBA)
+	}
+	if readBack.Primary.PartitionEntryLBA != 2 {
+		t.Errorf("expected Primary.PartitionEntryLBA == 2, got %d", readBack.Primary.PartitionEntryLBA)
+	}
+}
+
+func Test_Read_DetectsDivergentBackup(t *testing.T) {
+	const sectorSize = 512
+	const diskSizeSectors = 1 << 16
+
+	table, err := New(sectorSize, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := &seekBuffer{buf: make([]byte, diskSizeSectors*sectorSize)}
+	if err := table.Write(disk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewrite the backup header, CRC and all, with a different disk GUID:
+	// well-formed on its own, but disagreeing with the primary.
+	diverged := table.Backup
+	diverged.DiskGUID, err = NewRandomGUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := diverged.marshal(sectorSize)
+	copy(disk.buf[(diskSizeSectors-1)*sectorSize:], buf)
+
+	if _, err := Read(&seekBuffer{buf: disk.buf}, sectorSize, diskSizeSectors); err != ErrorHeadersDiverge {
+		t.Errorf("expected ErrorHeadersDiverge, got %v", err)
+	}
+}
+
+// seekBuffer is a minimal io.ReadWriteSeeker over a fixed-size byte slice,
+// used to exercise Write/Read without touching a real disk.
+type seekBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (this *seekBuffer) Write(p []byte) (int, error) {
+	n := copy(this.buf[this.pos:], p)
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Read(p []byte) (int, error) {
+	n := copy(p, this.buf[this.pos:])
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+		this.pos = offset
+	case 1:
+		this.pos += offset
+	case 2:
+		this.pos = int64(len(this.buf)) + offset
+	}
+	return this.pos, nil
+}
@@ -0,0 +1,99 @@
+package gpt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrorBadGUIDFormat = errors.New("GPT: Bad GUID string format")
+
+// GUID is a 16-byte GUID, stored on-disk in Microsoft's mixed-endian format:
+// the first three fields are little-endian, the last two (8 bytes) are kept
+// in the same byte order as they are printed (big-endian).
+type GUID [16]byte
+
+// String formats the GUID the way Microsoft tools print it, e.g.
+// "C12A7328-F81F-11D2-BA4B-00A0C93EC93B".
+func (this GUID) String() string {
+	return fmt.Sprintf("%08X-%04X-%04X-%04X-%012X",
+		binary.LittleEndian.Uint32(this[0:4]),
+		binary.LittleEndian.Uint16(this[4:6]),
+		binary.LittleEndian.Uint16(this[6:8]),
+		binary.BigEndian.Uint16(this[8:10]),
+		this[10:16])
+}
+
+// IsZero reports whether the GUID is all zero bytes (the "unused" type GUID).
+func (this GUID) IsZero() bool {
+	return this == GUID{}
+}
+
+// ParseGUID parses the standard dashed hex representation of a GUID.
+func ParseGUID(s string) (GUID, error) {
+	var guid GUID
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 5 {
+		return guid, ErrorBadGUIDFormat
+	}
+	if len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return guid, ErrorBadGUIDFormat
+	}
+
+	raw, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil || len(raw) != 16 {
+		return guid, ErrorBadGUIDFormat
+	}
+
+	binary.LittleEndian.PutUint32(guid[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(guid[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(guid[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(guid[8:16], raw[8:16])
+
+	return guid, nil
+}
+
+// MustParseGUID is like ParseGUID but panics on error. It is intended for
+// initializing the package-level partition type registry below.
+func MustParseGUID(s string) GUID {
+	guid, err := ParseGUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return guid
+}
+
+// NewRandomGUID generates a random RFC 4122 version 4 GUID, suitable for use
+// as a disk GUID or a partition's unique GUID.
+func NewRandomGUID() (GUID, error) {
+	var guid GUID
+	if _, err := rand.Read(guid[:]); err != nil {
+		return guid, err
+	}
+
+	// Set version (4) and variant (RFC 4122) bits. The version field is
+	// stored little-endian in bytes 6-7, so its high nibble is in byte 7.
+	guid[7] = guid[7]&0x0F | 0x40
+	guid[8] = guid[8]&0x3F | 0x80
+
+	return guid, nil
+}
+
+// Well-known partition type GUIDs. Not exhaustive, but covers the types most
+// tools care about.
+var (
+	TypeUnused             = GUID{}
+	TypeEFISystem          = MustParseGUID("C12A7328-F81F-11D2-BA4B-00A0C93EC93B")
+	TypeBIOSBoot           = MustParseGUID("21686148-6449-6E6F-744E-656564454649")
+	TypeMicrosoftReserved  = MustParseGUID("E3C9E316-0B5C-4DB8-817D-F92DF00215AE")
+	TypeMicrosoftBasicData = MustParseGUID("EBD0A0A2-B9E5-4433-87C0-68B6B72699C7")
+	TypeLinuxFilesystem    = MustParseGUID("0FC63DAF-8483-4772-8E79-3D69D8477DE4")
+	TypeLinuxSwap          = MustParseGUID("0657FD6D-A4AB-43C4-84E5-0933C84B4F4F")
+	TypeLinuxLVM           = MustParseGUID("E6D6D379-F507-44C2-A23C-238F2A3DF928")
+	TypeLinuxRAID          = MustParseGUID("A19D880F-05FC-4D3B-A006-743F0F84911E")
+	TypeZFS                = MustParseGUID("6A898CC3-1DD2-11B2-99A6-080020736631")
+)
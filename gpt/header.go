@@ -0,0 +1,109 @@
+package gpt
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+var ErrorBadGPTSignature = errors.New("GPT: Bad header signature")
+var ErrorBadHeaderCRC = errors.New("GPT: Header CRC32 mismatch")
+var ErrorBadPartitionArrayCRC = errors.New("GPT: Partition entries array CRC32 mismatch")
+
+const signature = "EFI PART"
+const headerRevision = 0x00010000
+const headerSize = 92
+
+const headerSignatureOffset = 0
+const headerRevisionOffset = 8
+const headerSizeOffset = 12
+const headerCRC32Offset = 16
+const headerMyLBAOffset = 24
+const headerAlternateLBAOffset = 32
+const headerFirstUsableLBAOffset = 40
+const headerLastUsableLBAOffset = 48
+const headerDiskGUIDOffset = 56
+const headerPartitionEntryLBAOffset = 72
+const headerNumberOfPartitionEntriesOffset = 80
+const headerSizeOfPartitionEntryOffset = 84
+const headerPartitionEntryArrayCRC32Offset = 88
+
+const defaultNumberOfPartitionEntries = 128
+const partitionEntrySize = 128
+
+// Header is the parsed content of a GPT header sector (either primary, at
+// LBA 1, or backup, at the last LBA of the disk).
+type Header struct {
+	MyLBA                    uint64
+	AlternateLBA             uint64
+	FirstUsableLBA           uint64
+	LastUsableLBA            uint64
+	DiskGUID                 GUID
+	PartitionEntryLBA        uint64
+	NumberOfPartitionEntries uint32
+	SizeOfPartitionEntry     uint32
+	PartitionEntryArrayCRC32 uint32
+}
+
+// parseHeader parses a single sector-sized buffer into a Header, verifying
+// the signature and the header's own CRC32 (computed with the on-disk CRC
+// field zeroed).
+func parseHeader(buf []byte) (*Header, error) {
+	if string(buf[headerSignatureOffset:headerSignatureOffset+8]) != signature {
+		return nil, ErrorBadGPTSignature
+	}
+
+	storedCRC := binary.LittleEndian.Uint32(buf[headerCRC32Offset : headerCRC32Offset+4])
+	if crc32ForCheck(buf) != storedCRC {
+		return nil, ErrorBadHeaderCRC
+	}
+
+	h := &Header{}
+	h.MyLBA = binary.LittleEndian.Uint64(buf[headerMyLBAOffset : headerMyLBAOffset+8])
+	h.AlternateLBA = binary.LittleEndian.Uint64(buf[headerAlternateLBAOffset : headerAlternateLBAOffset+8])
+	h.FirstUsableLBA = binary.LittleEndian.Uint64(buf[headerFirstUsableLBAOffset : headerFirstUsableLBAOffset+8])
+	h.LastUsableLBA = binary.LittleEndian.Uint64(buf[headerLastUsableLBAOffset : headerLastUsableLBAOffset+8])
+	copy(h.DiskGUID[:], buf[headerDiskGUIDOffset:headerDiskGUIDOffset+16])
+	h.PartitionEntryLBA = binary.LittleEndian.Uint64(buf[headerPartitionEntryLBAOffset : headerPartitionEntryLBAOffset+8])
+	h.NumberOfPartitionEntries = binary.LittleEndian.Uint32(buf[headerNumberOfPartitionEntriesOffset : headerNumberOfPartitionEntriesOffset+4])
+	h.SizeOfPartitionEntry = binary.LittleEndian.Uint32(buf[headerSizeOfPartitionEntryOffset : headerSizeOfPartitionEntryOffset+4])
+	h.PartitionEntryArrayCRC32 = binary.LittleEndian.Uint32(buf[headerPartitionEntryArrayCRC32Offset : headerPartitionEntryArrayCRC32Offset+4])
+
+	return h, nil
+}
+
+// marshal serializes the header into a full sectorSize buffer, computing and
+// filling in the header's own CRC32 last.
+func (this *Header) marshal(sectorSize int) []byte {
+	buf := make([]byte, sectorSize)
+
+	copy(buf[headerSignatureOffset:], signature)
+	binary.LittleEndian.PutUint32(buf[headerRevisionOffset:headerRevisionOffset+4], headerRevision)
+	binary.LittleEndian.PutUint32(buf[headerSizeOffset:headerSizeOffset+4], headerSize)
+	binary.LittleEndian.PutUint64(buf[headerMyLBAOffset:headerMyLBAOffset+8], this.MyLBA)
+	binary.LittleEndian.PutUint64(buf[headerAlternateLBAOffset:headerAlternateLBAOffset+8], this.AlternateLBA)
+	binary.LittleEndian.PutUint64(buf[headerFirstUsableLBAOffset:headerFirstUsableLBAOffset+8], this.FirstUsableLBA)
+	binary.LittleEndian.PutUint64(buf[headerLastUsableLBAOffset:headerLastUsableLBAOffset+8], this.LastUsableLBA)
+	copy(buf[headerDiskGUIDOffset:headerDiskGUIDOffset+16], this.DiskGUID[:])
+	binary.LittleEndian.PutUint64(buf[headerPartitionEntryLBAOffset:headerPartitionEntryLBAOffset+8], this.PartitionEntryLBA)
+	binary.LittleEndian.PutUint32(buf[headerNumberOfPartitionEntriesOffset:headerNumberOfPartitionEntriesOffset+4], this.NumberOfPartitionEntries)
+	binary.LittleEndian.PutUint32(buf[headerSizeOfPartitionEntryOffset:headerSizeOfPartitionEntryOffset+4], this.SizeOfPartitionEntry)
+	binary.LittleEndian.PutUint32(buf[headerPartitionEntryArrayCRC32Offset:headerPartitionEntryArrayCRC32Offset+4], this.PartitionEntryArrayCRC32)
+
+	binary.LittleEndian.PutUint32(buf[headerCRC32Offset:headerCRC32Offset+4], crc32ForCheck(buf))
+
+	return buf
+}
+
+// crc32ForCheck computes the header CRC32 (IEEE) over the first headerSize
+// bytes of buf with the on-disk CRC32 field zeroed out, per the UEFI spec.
+func crc32ForCheck(buf []byte) uint32 {
+	tmp := make([]byte, headerSize)
+	copy(tmp, buf[:headerSize])
+	binary.LittleEndian.PutUint32(tmp[headerCRC32Offset:headerCRC32Offset+4], 0)
+	return crc32.ChecksumIEEE(tmp)
+}
+
+func crc32Entries(buf []byte) uint32 {
+	return crc32.ChecksumIEEE(buf)
+}
@@ -0,0 +1,90 @@
+package gpt
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+const partitionTypeGUIDOffset = 0
+const partitionUniqueGUIDOffset = 16
+const partitionFirstLBAOffset = 32
+const partitionLastLBAOffset = 40
+const partitionAttributeFlagsOffset = 48
+const partitionNameOffset = 56
+const partitionNameChars = 36 // UTF-16LE code units, 72 bytes
+
+// Attribute flag bits, as defined by the UEFI spec. Bits 48-63 are reserved
+// for use by the partition type (e.g. the Microsoft basic data GUID defines
+// "read-only", "hidden" and "no drive letter" in that range).
+const (
+	AttrRequiredPartition  uint64 = 1 << 0
+	AttrNoBlockIOProtocol  uint64 = 1 << 1
+	AttrLegacyBIOSBootable uint64 = 1 << 2
+)
+
+// TypeAttributeBit returns the flag mask for one of the 16 type-specific
+// attribute bits (48-63). bit must be in [0, 15].
+func TypeAttributeBit(bit uint) uint64 {
+	return 1 << (48 + bit)
+}
+
+// Partition is one entry of the GPT partition entries array.
+type Partition struct {
+	TypeGUID       GUID
+	UniqueGUID     GUID
+	FirstLBA       uint64
+	LastLBA        uint64
+	AttributeFlags uint64
+	Name           string
+}
+
+// IsEmpty reports whether this entry is unused (a zero type GUID).
+func (this *Partition) IsEmpty() bool {
+	return this.TypeGUID.IsZero()
+}
+
+func parsePartitionEntry(buf []byte) *Partition {
+	p := &Partition{}
+	copy(p.TypeGUID[:], buf[partitionTypeGUIDOffset:partitionTypeGUIDOffset+16])
+	copy(p.UniqueGUID[:], buf[partitionUniqueGUIDOffset:partitionUniqueGUIDOffset+16])
+	p.FirstLBA = binary.LittleEndian.Uint64(buf[partitionFirstLBAOffset : partitionFirstLBAOffset+8])
+	p.LastLBA = binary.LittleEndian.Uint64(buf[partitionLastLBAOffset : partitionLastLBAOffset+8])
+	p.AttributeFlags = binary.LittleEndian.Uint64(buf[partitionAttributeFlagsOffset : partitionAttributeFlagsOffset+8])
+	p.Name = decodeName(buf[partitionNameOffset : partitionNameOffset+partitionNameChars*2])
+	return p
+}
+
+func (this *Partition) marshal(entrySize int) []byte {
+	buf := make([]byte, entrySize)
+	copy(buf[partitionTypeGUIDOffset:], this.TypeGUID[:])
+	copy(buf[partitionUniqueGUIDOffset:], this.UniqueGUID[:])
+	binary.LittleEndian.PutUint64(buf[partitionFirstLBAOffset:partitionFirstLBAOffset+8], this.FirstLBA)
+	binary.LittleEndian.PutUint64(buf[partitionLastLBAOffset:partitionLastLBAOffset+8], this.LastLBA)
+	binary.LittleEndian.PutUint64(buf[partitionAttributeFlagsOffset:partitionAttributeFlagsOffset+8], this.AttributeFlags)
+	copy(buf[partitionNameOffset:partitionNameOffset+partitionNameChars*2], encodeName(this.Name))
+	return buf
+}
+
+func encodeName(name string) []byte {
+	units := utf16.Encode([]rune(name))
+	if len(units) > partitionNameChars {
+		units = units[:partitionNameChars]
+	}
+	buf := make([]byte, partitionNameChars*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[i*2:i*2+2], u)
+	}
+	return buf
+}
+
+func decodeName(buf []byte) string {
+	units := make([]uint16, 0, partitionNameChars)
+	for i := 0; i < len(buf); i += 2 {
+		u := binary.LittleEndian.Uint16(buf[i : i+2])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
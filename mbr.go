@@ -14,10 +14,16 @@ var ErrorInvalidProtectiveType = errors.New("Invalid value for ProtectiveType")
 
 type MBR struct {
 	bytes []byte
+
+	// diskSizeSectors is an optional hint set via SetDiskSizeSectors,
+	// bounding the free-space gap after the last partition as seen by
+	// FreeSpace and AddPartition. Zero means "unknown": that trailing gap
+	// is left unbounded and omitted from FreeSpace's result.
+	diskSizeSectors uint32
 }
 
 type MBRPartition struct {
-	Num int
+	Num   int
 	bytes []byte
 }
 
@@ -25,6 +31,9 @@ type PartitionType byte
 
 const (
 	PART_EMPTY              = PartitionType(0)
+	PART_EXTENDED_CHS       = PartitionType(0x05)
+	PART_EXTENDED_LBA       = PartitionType(0x0F)
+	PART_EXTENDED_LINUX     = PartitionType(0x85)
 	PART_LINUX_SWAP_SOLARIS = PartitionType(0x82)
 	PART_LVM                = PartitionType(0x8E)
 	PART_HYBRID_GPT         = PartitionType(0xED)
@@ -73,6 +82,18 @@ func Read(disk io.Reader) (*MBR, error) {
 	return this, this.Check()
 }
 
+/*
+Check validates the primary partition table: the MBR signature, that no
+partition's last sector overflows a uint32, that every partition's bootable
+flag is well-formed, and that no two primary partitions intersect.
+
+Check deliberately does not validate logical partitions living behind an
+extended partition's EBR chain: unlike the primary table, which is fully
+contained in this MBR's own 512 bytes, the EBR chain lives elsewhere on
+disk, and Check's signature takes no disk argument to read it from.
+Callers that use extended partitions should also call
+CheckLogicalPartitions(disk).
+*/
 func (this *MBR) Check() error {
 	// Check signature
 	if this.bytes[mbrSignOffset] != 0x55 || this.bytes[mbrSignOffset+1] != 0xAA {
@@ -144,7 +165,7 @@ func (this MBR) GetPartition(num int) *MBRPartition {
 		return nil
 	}
 
-	var part *MBRPartition = &MBRPartition{Num:num}
+	var part *MBRPartition = &MBRPartition{Num: num}
 	partStart := mbrFirstPartEntryOffset + (num-1)*mbrPartEntrySize
 	part.bytes = this.bytes[partStart : partStart+mbrPartEntrySize]
 	return part
@@ -168,15 +189,16 @@ func (this MBR) IsGPT() bool {
 }
 
 // MakeProtective - Make this MBR a GPT Protective MBR
-//   sectorSize is either 512 or 4096. diskSize is the size of entire disk in bytes.
-//   https://en.wikipedia.org/wiki/GUID_Partition_Table#Protective_MBR_(LBA_0)
 //
-//  ProtectiveType value determines how the size of the partition is set.
-//    DefaultProtective - implementation default value
-//    MaxSize - Size of the ProtectiveMBR partition will be set to 0xFFFFFFFF
-//        While this is strictly outside the UEFI spec, it is the behavior
-//        of linux and windows partitioners.
-//    DiskSize - the actual length of the partition size size up to 0xFFFFFFFF - 1
+//	 sectorSize is either 512 or 4096. diskSize is the size of entire disk in bytes.
+//	 https://en.wikipedia.org/wiki/GUID_Partition_Table#Protective_MBR_(LBA_0)
+//
+//	ProtectiveType value determines how the size of the partition is set.
+//	  DefaultProtective - implementation default value
+//	  MaxSize - Size of the ProtectiveMBR partition will be set to 0xFFFFFFFF
+//	      While this is strictly outside the UEFI spec, it is the behavior
+//	      of linux and windows partitioners.
+//	  DiskSize - the actual length of the partition size size up to 0xFFFFFFFF - 1
 func (this *MBR) MakeProtective(sectorSize int, diskSize uint64, pType ProtectiveType) error {
 
 	if diskSize%uint64(sectorSize) != 0 {
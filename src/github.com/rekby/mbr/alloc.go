@@ -0,0 +1,188 @@
+package mbr
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrorNoFreePartitionSlot = errors.New("MBR: no free primary partition slot")
+var ErrorNoFreeSpace = errors.New("MBR: not enough free space for partition")
+var ErrorPartitionOverlap = errors.New("MBR: partition overlaps an existing one")
+
+// DefaultAlignmentSectors is the start-LBA alignment AddPartition uses when
+// the caller passes 0: 2048 sectors, i.e. 1 MiB, matching modern
+// partitioners (fdisk, parted, Windows).
+const DefaultAlignmentSectors = 2048
+
+// diskStartLBA is the first LBA a partition may legally start at; LBA 0
+// holds the MBR itself.
+const diskStartLBA = 1
+
+// Gap describes a run of free sectors on the disk, not occupied by any
+// primary partition.
+type Gap struct {
+	StartLBA   uint32
+	LenSectors uint32
+}
+
+/*
+SetDiskSizeSectors records the disk's total size in sectors. It is optional:
+FreeSpace and AddPartition work without it, but then treat the space after
+the last partition as unbounded (FreeSpace omits that trailing gap, and
+AddPartition may place a new partition anywhere after the last one, up to
+LBA 0xFFFFFFFF).
+*/
+func (this *MBR) SetDiskSizeSectors(sectors uint32) {
+	this.diskSizeSectors = sectors
+}
+
+/*
+FreeSpace returns the free-space gaps on the disk: before the first
+partition, between partitions, and after the last one, sorted by StartLBA.
+The trailing gap after the last partition (or from LBA 1 if there are no
+partitions) is only included if SetDiskSizeSectors has been called.
+*/
+func (this MBR) FreeSpace() []Gap {
+	type region struct{ start, end uint64 } // end exclusive
+	var used []region
+	for _, p := range this.GetAllPartitions() {
+		if !p.IsEmpty() {
+			used = append(used, region{uint64(p.GetLBAStart()), uint64(p.GetLBAStart()) + uint64(p.GetLBALen())})
+		}
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].start < used[j].start })
+
+	var gaps []Gap
+	cursor := uint64(diskStartLBA)
+	for _, r := range used {
+		if r.start > cursor {
+			gaps = append(gaps, Gap{StartLBA: uint32(cursor), LenSectors: uint32(r.start - cursor)})
+		}
+		if r.end > cursor {
+			cursor = r.end
+		}
+	}
+
+	if this.diskSizeSectors != 0 && uint64(this.diskSizeSectors) > cursor {
+		gaps = append(gaps, Gap{StartLBA: uint32(cursor), LenSectors: this.diskSizeSectors - uint32(cursor)})
+	}
+
+	return gaps
+}
+
+/*
+AddPartition finds the first empty primary partition slot and the first
+free-space gap big enough for sizeSectors, rounds the start LBA up to align
+sectors (0 means DefaultAlignmentSectors), and fills in the slot with
+partType, the computed LBAStart, sizeSectors and the bootable flag.
+
+Returns ErrorNoFreePartitionSlot if all four primary slots are in use, or
+ErrorNoFreeSpace if no gap is big enough once alignment is applied.
+*/
+func (this *MBR) AddPartition(sizeSectors uint32, partType PartitionType, bootable bool, align uint32) (*MBRPartition, error) {
+	if align == 0 {
+		align = DefaultAlignmentSectors
+	}
+
+	part := this.firstEmptySlot()
+	if part == nil {
+		return nil, ErrorNoFreePartitionSlot
+	}
+
+	startLBA, ok := this.findGap(sizeSectors, align)
+	if !ok {
+		return nil, ErrorNoFreeSpace
+	}
+
+	part.SetType(partType)
+	part.SetLBAStart(startLBA)
+	part.SetLBALen(sizeSectors)
+	part.SetBootable(bootable)
+	return part, nil
+}
+
+/*
+AddPartitionAt is like AddPartition, but places the partition at the
+caller-chosen startLBA instead of searching for a gap. It still requires an
+empty primary slot, and returns ErrorPartitionOverlap if
+[startLBA, startLBA+sizeSectors) intersects an existing partition or runs
+past a disk size set via SetDiskSizeSectors.
+*/
+func (this *MBR) AddPartitionAt(startLBA, sizeSectors uint32, partType PartitionType, bootable bool) (*MBRPartition, error) {
+	part := this.firstEmptySlot()
+	if part == nil {
+		return nil, ErrorNoFreePartitionSlot
+	}
+
+	end := uint64(startLBA) + uint64(sizeSectors)
+	if this.diskSizeSectors != 0 && end > uint64(this.diskSizeSectors) {
+		return nil, ErrorPartitionOverlap
+	}
+	for _, other := range this.GetAllPartitions() {
+		if other.IsEmpty() {
+			continue
+		}
+		otherStart := uint64(other.GetLBAStart())
+		otherEnd := otherStart + uint64(other.GetLBALen())
+		if uint64(startLBA) < otherEnd && otherStart < end {
+			return nil, ErrorPartitionOverlap
+		}
+	}
+
+	part.SetType(partType)
+	part.SetLBAStart(startLBA)
+	part.SetLBALen(sizeSectors)
+	part.SetBootable(bootable)
+	return part, nil
+}
+
+// firstEmptySlot returns the first primary partition with an empty type, or
+// nil if all four are in use.
+func (this MBR) firstEmptySlot() *MBRPartition {
+	for _, p := range this.GetAllPartitions() {
+		if p.IsEmpty() {
+			return p
+		}
+	}
+	return nil
+}
+
+// findGap returns the start LBA of the first free-space gap of at least
+// sizeSectors, aligned up to alignSectors, or false if none fits.
+func (this MBR) findGap(sizeSectors uint32, alignSectors uint32) (uint32, bool) {
+	for _, gap := range this.freeSpaceUnbounded() {
+		start := alignUp(gap.StartLBA, alignSectors)
+		if start < gap.StartLBA {
+			continue // alignUp overflowed past uint32 range
+		}
+		end := uint64(gap.StartLBA) + uint64(gap.LenSectors)
+		if uint64(start)+uint64(sizeSectors) <= end {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// freeSpaceUnbounded is FreeSpace, but always includes the trailing gap
+// after the last partition up to the max possible LBA, regardless of
+// whether SetDiskSizeSectors was called. AddPartition uses it so a missing
+// disk size doesn't prevent allocating into the space after the last
+// partition.
+func (this MBR) freeSpaceUnbounded() []Gap {
+	saved := this.diskSizeSectors
+	if saved == 0 {
+		this.diskSizeSectors = 0xFFFFFFFF
+	}
+	return this.FreeSpace()
+}
+
+func alignUp(lba uint32, align uint32) uint32 {
+	if align <= 1 {
+		return lba
+	}
+	rem := lba % align
+	if rem == 0 {
+		return lba
+	}
+	return lba + (align - rem)
+}
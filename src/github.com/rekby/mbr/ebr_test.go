@@ -0,0 +1,252 @@
+package mbr
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// buildDiskWithEBRChain builds an in-memory disk image with an extended
+// partition starting at LBA 100 containing two logical partitions, each
+// preceded by its own EBR.
+func buildDiskWithEBRChain(t *testing.T) *bytes.Reader {
+	const diskSectors = 300
+	disk := make([]byte, diskSectors*mbrSize)
+
+	mbrSector := disk[0:mbrSize]
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+	ext := m.GetPartition(1)
+	ext.SetType(PART_EXTENDED_LBA)
+	ext.SetLBAStart(100)
+	ext.SetLBALen(100)
+
+	writeEBR := func(lba uint32, logicalStart, logicalLen uint32, nextRel uint32, hasNext bool) {
+		sector := disk[lba*mbrSize : lba*mbrSize+mbrSize]
+		sector[mbrSignOffset], sector[mbrSignOffset+1] = 0x55, 0xAA
+		entry := ebrPartitionEntry(sector, 0)
+		entry.SetType(PART_LINUX_SWAP_SOLARIS)
+		entry.SetLBAStart(logicalStart)
+		entry.SetLBALen(logicalLen)
+		if hasNext {
+			next := ebrPartitionEntry(sector, 1)
+			next.SetType(PART_EXTENDED_LBA)
+			next.SetLBAStart(nextRel)
+		}
+	}
+
+	// First EBR at LBA 100, logical partition at LBA 101, len 9.
+	writeEBR(100, 1, 9, 20, true)
+	// Second EBR at LBA 120 (100+20), logical partition at LBA 121, len 9.
+	writeEBR(120, 1, 9, 0, false)
+
+	if err := m.Check(); err != nil {
+		t.Fatalf("unexpected MBR error: %v", err)
+	}
+
+	return bytes.NewReader(disk)
+}
+
+func Test_GetLogicalPartitions(t *testing.T) {
+	disk := buildDiskWithEBRChain(t)
+
+	mbrSector := make([]byte, mbrSize)
+	if _, err := disk.ReadAt(mbrSector, 0); err != nil {
+		t.Fatal(err)
+	}
+	m := &MBR{bytes: mbrSector}
+
+	logicals, err := m.GetLogicalPartitions(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logicals) != 2 {
+		t.Fatalf("expected 2 logical partitions, got %d", len(logicals))
+	}
+	if logicals[0].Num != 5 || logicals[1].Num != 6 {
+		t.Errorf("unexpected partition numbering: %d, %d", logicals[0].Num, logicals[1].Num)
+	}
+	if logicals[0].GetLBAStart() != 101 {
+		t.Errorf("expected absolute LBA 101, got %d", logicals[0].GetLBAStart())
+	}
+	if logicals[1].GetLBAStart() != 121 {
+		t.Errorf("expected absolute LBA 121, got %d", logicals[1].GetLBAStart())
+	}
+
+	if err := m.CheckLogicalPartitions(disk); err != nil {
+		t.Errorf("expected logical partitions to validate, got: %v", err)
+	}
+}
+
+// seekBuffer is a minimal io.ReadWriteSeeker over a fixed-size byte slice,
+// used to exercise AppendLogicalPartition/RemoveLogicalPartition without
+// touching a real disk.
+type seekBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (this *seekBuffer) Write(p []byte) (int, error) {
+	n := copy(this.buf[this.pos:], p)
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Read(p []byte) (int, error) {
+	n := copy(p, this.buf[this.pos:])
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+		this.pos = offset
+	case 1:
+		this.pos += offset
+	case 2:
+		this.pos = int64(len(this.buf)) + offset
+	}
+	return this.pos, nil
+}
+
+// buildDiskWithThreeAppendedLogicals builds an extended partition at LBA
+// 100, len 100 with nothing but a signed, empty head EBR, then appends three
+// logical partitions through AppendLogicalPartition itself (the same path
+// real callers use), each preceded by a freshly-created trailing EBR. None
+// of the appends ever fills in the head EBR's own entry.
+func buildDiskWithThreeAppendedLogicals(t *testing.T) (*seekBuffer, *MBR) {
+	const diskSectors = 300
+	disk := &seekBuffer{buf: make([]byte, diskSectors*mbrSize)}
+
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+	ext := m.GetPartition(1)
+	ext.SetType(PART_EXTENDED_LBA)
+	ext.SetLBAStart(100)
+	ext.SetLBALen(100)
+
+	headEBR := make([]byte, mbrSize)
+	headEBR[mbrSignOffset], headEBR[mbrSignOffset+1] = 0x55, 0xAA
+	if err := writeSector(disk, 100, headEBR); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lbaStart := range []uint32{110, 130, 150} {
+		if err := m.AppendLogicalPartition(disk, PART_LINUX_SWAP_SOLARIS, lbaStart, 9); err != nil {
+			t.Fatalf("AppendLogicalPartition(%d): %v", lbaStart, err)
+		}
+	}
+
+	return disk, m
+}
+
+func assertLogicalLBAs(t *testing.T, m *MBR, disk io.ReadSeeker, wantLBAs ...uint32) {
+	t.Helper()
+	logicals, err := m.GetLogicalPartitions(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logicals) != len(wantLBAs) {
+		t.Fatalf("expected %d logical partitions, got %d: %+v", len(wantLBAs), len(logicals), logicals)
+	}
+	for i, want := range wantLBAs {
+		if logicals[i].Num != logicalPartitionNumFirst+i {
+			t.Errorf("partition %d: expected Num %d, got %d", i, logicalPartitionNumFirst+i, logicals[i].Num)
+		}
+		if logicals[i].GetLBAStart() != want {
+			t.Errorf("partition %d: expected LBA %d, got %d", i, want, logicals[i].GetLBAStart())
+		}
+	}
+}
+
+func Test_AppendLogicalPartition_BuildsExpectedChain(t *testing.T) {
+	disk, m := buildDiskWithThreeAppendedLogicals(t)
+	assertLogicalLBAs(t, m, disk, 110, 130, 150)
+}
+
+func Test_RemoveLogicalPartition_Tail(t *testing.T) {
+	disk, m := buildDiskWithThreeAppendedLogicals(t)
+
+	if err := m.RemoveLogicalPartition(disk, 7); err != nil {
+		t.Fatal(err)
+	}
+	assertLogicalLBAs(t, m, disk, 110, 130)
+}
+
+func Test_RemoveLogicalPartition_Interior(t *testing.T) {
+	disk, m := buildDiskWithThreeAppendedLogicals(t)
+
+	if err := m.RemoveLogicalPartition(disk, 6); err != nil {
+		t.Fatal(err)
+	}
+	assertLogicalLBAs(t, m, disk, 110, 150)
+}
+
+func Test_RemoveLogicalPartition_Head(t *testing.T) {
+	disk, m := buildDiskWithThreeAppendedLogicals(t)
+
+	if err := m.RemoveLogicalPartition(disk, 5); err != nil {
+		t.Fatal(err)
+	}
+	assertLogicalLBAs(t, m, disk, 130, 150)
+}
+
+// buildDiskWithFreshExtendedPartition builds an extended partition at LBA
+// 100, len 100 whose own LBA has never been written to (all zero bytes),
+// the state left behind by AddPartition+SetType(PART_EXTENDED_LBA) alone.
+func buildDiskWithFreshExtendedPartition() (*seekBuffer, *MBR) {
+	const diskSectors = 300
+	disk := &seekBuffer{buf: make([]byte, diskSectors*mbrSize)}
+
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+	ext := m.GetPartition(1)
+	ext.SetType(PART_EXTENDED_LBA)
+	ext.SetLBAStart(100)
+	ext.SetLBALen(100)
+
+	return disk, m
+}
+
+func Test_GetLogicalPartitions_FreshExtendedPartition(t *testing.T) {
+	disk, m := buildDiskWithFreshExtendedPartition()
+
+	logicals, err := m.GetLogicalPartitions(disk)
+	if err != nil {
+		t.Fatalf("expected no error reading a never-written extended partition, got: %v", err)
+	}
+	if logicals != nil {
+		t.Errorf("expected no logical partitions, got %v", logicals)
+	}
+}
+
+func Test_AppendLogicalPartition_InitializesHeadEBR(t *testing.T) {
+	disk, m := buildDiskWithFreshExtendedPartition()
+
+	if err := m.AppendLogicalPartition(disk, PART_LINUX_SWAP_SOLARIS, 110, 9); err != nil {
+		t.Fatalf("first AppendLogicalPartition on a fresh extended partition: %v", err)
+	}
+	assertLogicalLBAs(t, m, disk, 110)
+
+	if err := m.AppendLogicalPartition(disk, PART_LINUX_SWAP_SOLARIS, 130, 9); err != nil {
+		t.Fatal(err)
+	}
+	assertLogicalLBAs(t, m, disk, 110, 130)
+}
+
+func Test_GetLogicalPartitions_NoExtended(t *testing.T) {
+	mbrSector := make([]byte, mbrSize)
+	mbrSector[mbrSignOffset], mbrSector[mbrSignOffset+1] = 0x55, 0xAA
+	m := &MBR{bytes: mbrSector}
+
+	logicals, err := m.GetLogicalPartitions(bytes.NewReader(make([]byte, mbrSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logicals != nil {
+		t.Errorf("expected no logical partitions, got %v", logicals)
+	}
+}
@@ -9,15 +9,45 @@ var ErrorBadMbrSign = errors.New("MBR: Bad signature")
 var ErrorPartitionsIntersection = errors.New("MBR: Partitions have intersections")
 var ErrorPartitionLastSectorHigh = errors.New("MBR: Last sector have very high number")
 var ErrorPartitionBootFlag = errors.New("MBR: Bad value in boot flag")
+var ErrorDiskSizeNotEvenSectors = errors.New("MBR: Disk size is not evenly divisible by sector size")
+var ErrorInvalidProtectiveType = errors.New("Invalid value for ProtectiveType")
 
 type MBR struct {
 	bytes []byte
+
+	// diskSizeSectors is an optional hint set via SetDiskSizeSectors,
+	// bounding the free-space gap after the last partition as seen by
+	// FreeSpace and AddPartition. Zero means "unknown": that trailing gap
+	// is left unbounded and omitted from FreeSpace's result.
+	diskSizeSectors uint32
 }
 
 type MBRPartition struct {
+	Num   int
 	bytes []byte
 }
 
+type PartitionType byte
+
+const (
+	PART_EMPTY              = PartitionType(0)
+	PART_EXTENDED_CHS       = PartitionType(0x05)
+	PART_EXTENDED_LBA       = PartitionType(0x0F)
+	PART_EXTENDED_LINUX     = PartitionType(0x85)
+	PART_LINUX_SWAP_SOLARIS = PartitionType(0x82)
+	PART_LVM                = PartitionType(0x8E)
+	PART_HYBRID_GPT         = PartitionType(0xED)
+	PART_GPT                = PartitionType(0xEE)
+)
+
+type ProtectiveType int
+
+const (
+	DiskSize          = ProtectiveType(1)
+	MaxSize           = ProtectiveType(2)
+	DefaultProtective = ProtectiveType(0)
+)
+
 const mbrFirstPartEntryOffset = 446 // bytes
 const mbrPartEntrySize = 16         // bytes
 const mbrSize = 512                 // bytes
@@ -28,7 +58,6 @@ const partitionTypeOffset = 4       // bytes
 const partitionLBAStartOffset = 8   // bytes
 const partitionLBALengthOffset = 12 // bytes
 
-const partitionEmptyType = 0
 const partitionNumFirst = 1
 const partitionNumLast = 4
 const partitionBootableValue = 0x80
@@ -53,6 +82,18 @@ func Read(disk io.Reader) (*MBR, error) {
 	return this, this.Check()
 }
 
+/*
+Check validates the primary partition table: the MBR signature, that no
+partition's last sector overflows a uint32, that every partition's bootable
+flag is well-formed, and that no two primary partitions intersect.
+
+Check deliberately does not validate logical partitions living behind an
+extended partition's EBR chain: unlike the primary table, which is fully
+contained in this MBR's own 512 bytes, the EBR chain lives elsewhere on
+disk, and Check's signature takes no disk argument to read it from.
+Callers that use extended partitions should also call
+CheckLogicalPartitions(disk).
+*/
 func (this *MBR) Check() error {
 	// Check signature
 	if this.bytes[mbrSignOffset] != 0x55 || this.bytes[mbrSignOffset+1] != 0xAA {
@@ -60,6 +101,7 @@ func (this *MBR) Check() error {
 	}
 
 	// Check partitions
+	maxLen := uint64(0xFFFFFFFF)
 	for l := partitionNumFirst; l <= partitionNumLast; l++ {
 		lp := this.GetPartition(l)
 		if lp.IsEmpty() {
@@ -67,8 +109,13 @@ func (this *MBR) Check() error {
 		}
 
 		// Check if partition last sector out of uint32 bounds
-		if uint64(lp.GetLBAStart())+uint64(lp.GetLBALen()) > uint64(0xFFFFFFFF) {
-			return ErrorPartitionLastSectorHigh
+		last := uint64(lp.GetLBAStart()) + uint64(lp.GetLBALen())
+		if last > maxLen {
+			// Most/All GPT partitioners write a length for the Protective MBR of maxLen
+			// even though it is strictly out of bounds. Specifically allow for that.
+			if !(l == partitionNumFirst && last == maxLen+1 && lp.GetType() == PART_GPT) {
+				return ErrorPartitionLastSectorHigh
+			}
 		}
 
 		// Check partition bootable status
@@ -118,12 +165,80 @@ func (this MBR) GetPartition(num int) *MBRPartition {
 		return nil
 	}
 
-	var part *MBRPartition = &MBRPartition{}
+	var part *MBRPartition = &MBRPartition{Num: num}
 	partStart := mbrFirstPartEntryOffset + (num-1)*mbrPartEntrySize
 	part.bytes = this.bytes[partStart : partStart+mbrPartEntrySize]
 	return part
 }
 
+func (this MBR) GetAllPartitions() []*MBRPartition {
+	res := make([]*MBRPartition, 4)
+	for i := 0; i < 4; i++ {
+		res[i] = this.GetPartition(i + 1)
+	}
+	return res
+}
+
+func (this MBR) IsGPT() bool {
+	for _, part := range this.GetAllPartitions() {
+		if part.GetType() == PART_GPT || part.GetType() == PART_HYBRID_GPT {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeProtective - Make this MBR a GPT Protective MBR
+//
+//	 sectorSize is either 512 or 4096. diskSize is the size of entire disk in bytes.
+//	 https://en.wikipedia.org/wiki/GUID_Partition_Table#Protective_MBR_(LBA_0)
+//
+//	ProtectiveType value determines how the size of the partition is set.
+//	  DefaultProtective - implementation default value
+//	  MaxSize - Size of the ProtectiveMBR partition will be set to 0xFFFFFFFF
+//	      While this is strictly outside the UEFI spec, it is the behavior
+//	      of linux and windows partitioners.
+//	  DiskSize - the actual length of the partition size size up to 0xFFFFFFFF - 1
+func (this *MBR) MakeProtective(sectorSize int, diskSize uint64, pType ProtectiveType) error {
+
+	if diskSize%uint64(sectorSize) != 0 {
+		return ErrorDiskSizeNotEvenSectors
+	}
+	this.FixSignature()
+
+	ptLBAStart := uint32(1)
+	ptLBALen := uint32(0xFFFFFFFF)
+
+	if pType == DiskSize {
+		max := uint64(0xFFFFFFFF)
+		actual := diskSize/uint64(sectorSize) - uint64(ptLBAStart)
+		if actual > max {
+			ptLBALen = uint32(max)
+		} else {
+			ptLBALen = uint32(actual)
+		}
+	} else if pType != MaxSize && pType != DefaultProtective {
+		return ErrorInvalidProtectiveType
+	}
+
+	pt := this.GetPartition(1)
+	pt.SetType(PART_GPT)
+	pt.SetLBAStart(ptLBAStart)
+	pt.SetLBALen(ptLBALen)
+	pt.bytes[partitionBootableOffset] = partitionNonBootableValue
+
+	// zero the other partitions.
+	for pnum := 2; pnum <= 4; pnum++ {
+		pt := this.GetPartition(pnum)
+		pt.SetType(PART_EMPTY)
+		pt.SetLBAStart(0)
+		pt.SetLBALen(0)
+		pt.bytes[partitionBootableOffset] = partitionNonBootableValue
+	}
+
+	return nil
+}
+
 /*
 Return number of first sector of partition. Numbers starts from 1.
 */
@@ -138,11 +253,33 @@ func (this *MBRPartition) GetLBALen() uint32 {
 	return readLittleEndianUINT32(this.bytes[partitionLBALengthOffset : partitionLBALengthOffset+4])
 }
 
+/*
+Return number of last setor if partition.
+
+If last sector num more then max uint32 - panic. It mean error in metadata.
+*/
+func (this *MBRPartition) GetLBALast() uint32 {
+	last := uint64(this.GetLBAStart()) + uint64(this.GetLBALen()) - 1
+
+	// If last > max uint32 - panic
+	if last > uint64(0xFFFFFFFF) {
+		panic(errors.New("Overflow while calc last sector. Max sector number in mbr must be less or equal 0xFFFFFFFF"))
+	}
+	return uint32(last)
+}
+
+func (this *MBRPartition) GetType() PartitionType {
+	return PartitionType(this.bytes[partitionTypeOffset])
+}
+func (this *MBRPartition) SetType(t PartitionType) {
+	this.bytes[partitionTypeOffset] = byte(t)
+}
+
 /*
 Return true if partition have empty type
 */
 func (this *MBRPartition) IsEmpty() bool {
-	return this.bytes[partitionTypeOffset] == partitionEmptyType
+	return this.GetType() == PART_EMPTY
 }
 
 /*
@@ -159,6 +296,24 @@ func (this *MBRPartition) SetLBALen(sectorCount uint32) {
 	writeLittleEndianUINT32(this.bytes[partitionLBALengthOffset:partitionLBALengthOffset+4], sectorCount)
 }
 
+/*
+Set the Bootable flag on this partition.
+*/
+func (this *MBRPartition) SetBootable(bootable bool) {
+	if bootable {
+		this.bytes[partitionBootableOffset] = partitionBootableValue
+	} else {
+		this.bytes[partitionBootableOffset] = partitionNonBootableValue
+	}
+}
+
+/*
+Return true if this partition's bootable flag is set.
+*/
+func (this *MBRPartition) IsBootable() bool {
+	return this.bytes[partitionBootableOffset] == partitionBootableValue
+}
+
 func writeLittleEndianUINT32(buf []byte, val uint32) {
 	buf[0] = byte(val & 0xFF)
 	buf[1] = byte(val >> 8 & 0xFF)
@@ -1,4 +1,4 @@
-package parttable
+package mbr
 
 import (
 	"testing"
@@ -26,10 +26,10 @@ func Test_fixSignature(t *testing.T){
 	}
 
 	var buf bytes.Buffer
-	buf.Write([512]byte{})
-	mbr, _ := Read(buf)
+	buf.Write(make([]byte, 512))
+	mbr, _ := Read(&buf)
 	mbr.FixSignature()
-	if mbr.bytes[mbrSignOffset] != 0x55 || mbr.bytes[mbrSignOffset] != 0xAA {
+	if mbr.bytes[mbrSignOffset] != 0x55 || mbr.bytes[mbrSignOffset+1] != 0xAA {
 		t.Error("Error")
 	}
 }
\ No newline at end of file
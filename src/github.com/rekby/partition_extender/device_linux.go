@@ -0,0 +1,34 @@
+// +build linux
+
+package partitionextender
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// See linux/fs.h: BLKGETSIZE64 = _IOR(0x12, 114, size_t), BLKRRPART = _IO(0x12, 95).
+const blkGetSize64 = 0x80081272
+const blkRRPart = 0x125F
+
+func blockDeviceSize(f *os.File) (uint64, error) {
+	var size uint64
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), blkGetSize64, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return size, nil
+}
+
+// reReadPartitionTable asks the kernel to re-read the partition table of
+// the block device behind f (BLKRRPART), so a grown partition becomes
+// visible without a reboot. It is a no-op error-wise on plain image files:
+// the ioctl simply fails with ENOTTY/EINVAL, which callers may ignore.
+func reReadPartitionTable(f *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), blkRRPart, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
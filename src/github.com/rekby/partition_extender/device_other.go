@@ -0,0 +1,13 @@
+// +build !linux
+
+package partitionextender
+
+import "os"
+
+func blockDeviceSize(f *os.File) (uint64, error) {
+	return 0, errNotABlockDevice
+}
+
+func reReadPartitionTable(f *os.File) error {
+	return nil
+}
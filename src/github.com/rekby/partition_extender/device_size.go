@@ -0,0 +1,24 @@
+package partitionextender
+
+import (
+	"errors"
+	"os"
+)
+
+var errNotABlockDevice = errors.New("not a block device")
+
+// deviceSizeSectors returns the size of f, in 512-byte sectors. For a block
+// device it asks the kernel directly (BLKGETSIZE64 on Linux); otherwise,
+// and as a fallback if that fails, it falls back to the size reported by
+// os.Stat, which is what image files need.
+func deviceSizeSectors(f *os.File) (uint64, error) {
+	if size, err := blockDeviceSize(f); err == nil {
+		return size / sectorSize, nil
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stat.Size()) / sectorSize, nil
+}
@@ -0,0 +1,103 @@
+package partitionextender
+
+import (
+	"errors"
+	"io"
+
+	"github.com/rekby/mbr"
+)
+
+var ErrPartitionIsLogical = errors.New("partition number must be 1-4 (logical partitions can't be grown)")
+var ErrPartitionEmpty = errors.New("partition is empty")
+var ErrIsGPT = errors.New("partition table is GPT, not MBR")
+var ErrAlreadyMax = errors.New("ALREADY_MAX")
+var ErrNotEnoughSpace = errors.New("not enough free space after the partition")
+
+// sectorSize is the MBR LBA unit. MBR partition tables always address
+// sectors in 512-byte units, regardless of the disk's physical sector size.
+const sectorSize = 512
+
+/*
+ExtendPartition grows partition partNum (1-4) on the MBR read from rw.
+
+If growSectors is 0, the partition is grown to fill all free space up to
+the start of the next partition, or up to diskSizeSectors if there is none.
+Otherwise the partition is grown by exactly growSectors, and
+ErrNotEnoughSpace is returned if that doesn't fit.
+
+It refuses to operate on logical partitions, on a GPT disk, or on an empty
+partition slot, and never shrinks a partition. On success it writes the
+updated MBR back to rw and returns the partition's new length in sectors.
+*/
+func ExtendPartition(rw io.ReadWriteSeeker, partNum int, growSectors uint64, diskSizeSectors uint64) (uint32, error) {
+	if partNum < 1 || partNum > 4 {
+		return 0, ErrPartitionIsLogical
+	}
+
+	if _, err := rw.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	m, err := mbr.Read(rw)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.IsGPT() {
+		return 0, ErrIsGPT
+	}
+
+	part := m.GetPartition(partNum)
+	if part.IsEmpty() {
+		return 0, ErrPartitionEmpty
+	}
+
+	available := freeSpaceAfter(m, part, diskSizeSectors)
+
+	var newLen uint64
+	if growSectors == 0 {
+		if available == 0 {
+			return 0, ErrAlreadyMax
+		}
+		newLen = uint64(part.GetLBALen()) + available
+	} else {
+		if growSectors > available {
+			return 0, ErrNotEnoughSpace
+		}
+		newLen = uint64(part.GetLBALen()) + growSectors
+	}
+
+	part.SetLBALen(uint32(newLen))
+
+	if _, err := rw.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if err := m.Write(rw); err != nil {
+		return 0, err
+	}
+
+	return part.GetLBALen(), nil
+}
+
+// freeSpaceAfter returns the number of sectors free between the end of
+// part and the start of the next partition on the disk (whichever of the
+// other three partitions starts soonest after it), or the end of the disk
+// if there is none.
+func freeSpaceAfter(m *mbr.MBR, part *mbr.MBRPartition, diskSizeSectors uint64) uint64 {
+	end := uint64(part.GetLBAStart()) + uint64(part.GetLBALen())
+
+	boundary := diskSizeSectors
+	for _, other := range m.GetAllPartitions() {
+		if other.Num == part.Num || other.IsEmpty() {
+			continue
+		}
+		start := uint64(other.GetLBAStart())
+		if start >= end && start < boundary {
+			boundary = start
+		}
+	}
+
+	if boundary <= end {
+		return 0
+	}
+	return boundary - end
+}
@@ -0,0 +1,160 @@
+package partitionextender
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rekby/mbr"
+)
+
+// seekBuffer is a minimal io.ReadWriteSeeker over a fixed-size byte slice,
+// used to exercise ExtendPartition against an in-memory disk image.
+type seekBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (this *seekBuffer) Write(p []byte) (int, error) {
+	n := copy(this.buf[this.pos:], p)
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Read(p []byte) (int, error) {
+	n := copy(p, this.buf[this.pos:])
+	this.pos += int64(n)
+	return n, nil
+}
+
+func (this *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+		this.pos = offset
+	case 1:
+		this.pos += offset
+	case 2:
+		this.pos = int64(len(this.buf)) + offset
+	}
+	return this.pos, nil
+}
+
+// buildDisk returns an in-memory disk image of diskSizeSectors sectors
+// whose partition 1 occupies [lbaStart, lbaStart+lbaLen).
+func buildDisk(t *testing.T, diskSizeSectors uint64, lbaStart, lbaLen uint32) *seekBuffer {
+	disk := &seekBuffer{buf: make([]byte, diskSizeSectors*sectorSize)}
+
+	m, _ := mbr.Read(bytes.NewReader(make([]byte, sectorSize)))
+	part := m.GetPartition(1)
+	part.SetType(mbr.PART_LINUX_SWAP_SOLARIS)
+	part.SetLBAStart(lbaStart)
+	part.SetLBALen(lbaLen)
+	m.FixSignature()
+
+	if err := m.Write(disk); err != nil {
+		t.Fatal(err)
+	}
+	disk.pos = 0
+	return disk
+}
+
+func Test_ExtendPartition_ToEndOfDisk(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	newLen, err := ExtendPartition(disk, 1, 0, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint32(diskSizeSectors - 100); newLen != want {
+		t.Errorf("expected new length %d, got %d", want, newLen)
+	}
+}
+
+func Test_ExtendPartition_ToNextPartition(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	disk.pos = 0
+	m, err := mbr.Read(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := m.GetPartition(2)
+	other.SetType(mbr.PART_LINUX_SWAP_SOLARIS)
+	other.SetLBAStart(300)
+	other.SetLBALen(50)
+	disk.pos = 0
+	if err := m.Write(disk); err != nil {
+		t.Fatal(err)
+	}
+
+	newLen, err := ExtendPartition(disk, 1, 0, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint32(300 - 100); newLen != want {
+		t.Errorf("expected new length %d, got %d", want, newLen)
+	}
+}
+
+func Test_ExtendPartition_ByFixedAmount(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	newLen, err := ExtendPartition(disk, 1, 20, diskSizeSectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newLen != 70 {
+		t.Errorf("expected new length 70, got %d", newLen)
+	}
+
+	if _, err := ExtendPartition(disk, 1, 10000, diskSizeSectors); err != ErrNotEnoughSpace {
+		t.Errorf("expected ErrNotEnoughSpace, got %v", err)
+	}
+}
+
+func Test_ExtendPartition_AlreadyMax(t *testing.T) {
+	const diskSizeSectors = 150
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	if _, err := ExtendPartition(disk, 1, 0, diskSizeSectors); err != ErrAlreadyMax {
+		t.Errorf("expected ErrAlreadyMax, got %v", err)
+	}
+}
+
+func Test_ExtendPartition_RejectsLogicalPartitionNumber(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	if _, err := ExtendPartition(disk, 5, 0, diskSizeSectors); err != ErrPartitionIsLogical {
+		t.Errorf("expected ErrPartitionIsLogical, got %v", err)
+	}
+}
+
+func Test_ExtendPartition_RejectsEmptyPartition(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := buildDisk(t, diskSizeSectors, 100, 50)
+
+	if _, err := ExtendPartition(disk, 2, 0, diskSizeSectors); err != ErrPartitionEmpty {
+		t.Errorf("expected ErrPartitionEmpty, got %v", err)
+	}
+}
+
+func Test_ExtendPartition_RejectsGPT(t *testing.T) {
+	const diskSizeSectors = 1000
+	disk := &seekBuffer{buf: make([]byte, diskSizeSectors*sectorSize)}
+
+	m, _ := mbr.Read(bytes.NewReader(make([]byte, sectorSize)))
+	if err := m.MakeProtective(sectorSize, diskSizeSectors*sectorSize, mbr.DefaultProtective); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Write(disk); err != nil {
+		t.Fatal(err)
+	}
+	disk.pos = 0
+
+	if _, err := ExtendPartition(disk, 1, 0, diskSizeSectors); err != ErrIsGPT {
+		t.Errorf("expected ErrIsGPT, got %v", err)
+	}
+}
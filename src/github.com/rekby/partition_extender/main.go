@@ -1,31 +1,74 @@
 package partitionextender
+
 import (
 	"fmt"
 	"os"
-	"unicode"
+	"strconv"
+	"strings"
 )
 
-func Main(){
+func Main() {
 	if len(os.Args) < 3 || os.Args[1] == "--help" {
 		printUsage()
 		return
 	}
 
 	path := os.Args[1]
-	if len(path) < 2 || !unicode.IsDigit(rune( path[len(path)-1])) || unicode.IsDigit(rune( path[len(path)-2])) {
+
+	partNum, err := strconv.Atoi(os.Args[2])
+	if err != nil || partNum < 1 || partNum > 4 {
 		fmt.Println("ERR\nBad partition number")
 		printUsage()
-		return
+		os.Exit(2)
 	}
 
-	stat, err := os.Stat(path)
+	var growGiB uint64
+	if len(os.Args) >= 4 {
+		sizeArg := os.Args[3]
+		if !strings.HasPrefix(sizeArg, "+") {
+			fmt.Println("ERR\nBad size, expected +SIZE")
+			printUsage()
+			os.Exit(2)
+		}
+		growGiB, err = strconv.ParseUint(sizeArg[1:], 10, 64)
+		if err != nil {
+			fmt.Println("ERR\nBad size, expected +SIZE")
+			printUsage()
+			os.Exit(2)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
 	if err != nil {
-		fmt.Println("ERR\nCan't stat partition path")
+		fmt.Println("ERR\n" + err.Error())
+		os.Exit(2)
 	}
+	defer f.Close()
 
+	diskSizeSectors, err := deviceSizeSectors(f)
+	if err != nil {
+		fmt.Println("ERR\n" + err.Error())
+		os.Exit(2)
+	}
+
+	growSectors := growGiB * (1 << 30) / sectorSize
+
+	_, err = ExtendPartition(f, partNum, growSectors, diskSizeSectors)
+	switch err {
+	case nil:
+		// Best-effort: this fails harmlessly on plain image files.
+		reReadPartitionTable(f)
+		fmt.Println("OK")
+	case ErrAlreadyMax:
+		fmt.Println("ALREADY_MAX")
+		os.Exit(1)
+	default:
+		fmt.Println("ERR\n" + err.Error())
+		os.Exit(2)
+	}
 }
 
-func printUsage(){
+func printUsage() {
 	fmt.Printf(`%s <device> <partnumber> [+SIZE]
 <devide> - full path for file of device, which need to extend, for example /dev/sda or /dev/hdd
 <partnumber> - number of partition: 1,2,3 or 4
@@ -45,4 +88,4 @@ example usages:
 partextender /dev/sda 2
 partextender /dev/sda 2 +10
 `, os.Args[0])
-}
\ No newline at end of file
+}